@@ -0,0 +1,245 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package analyzer defines an Analyzer that reports misuses of package errc.
+//
+// The analyzer reports
+//   - a Catcher that is not followed by a deferred call to Handle,
+//   - a call to Handle that is not deferred,
+//   - the use of a Catcher after a non-deferred call to Handle, and
+//   - a Catcher passed by value, which copies its state.
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const errcPath = "github.com/mpvl/errc"
+
+// Analyzer reports misuses of package errc.
+var Analyzer = &analysis.Analyzer{
+	Name:     "errc",
+	Doc:      "check for misuses of package errc",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// catchFuncs are the functions of package errc that return a Catcher.
+var catchFuncs = map[string]bool{
+	"Catch":        true,
+	"CatchContext": true,
+	"CatchLite":    true,
+	"CatchSafe":    true,
+	"CatchT":       true,
+	"Pooled":       true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if isErrcPkg(pass.Pkg.Path()) {
+		return nil, nil
+	}
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodes := []ast.Node{
+		(*ast.BlockStmt)(nil),
+		(*ast.CaseClause)(nil),
+		(*ast.CommClause)(nil),
+		(*ast.FuncType)(nil),
+		(*ast.CallExpr)(nil),
+	}
+	insp.Preorder(nodes, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			checkStmts(pass, n.List)
+		case *ast.CaseClause:
+			checkStmts(pass, n.Body)
+		case *ast.CommClause:
+			checkStmts(pass, n.Body)
+		case *ast.FuncType:
+			checkParams(pass, n)
+		case *ast.CallExpr:
+			checkArgs(pass, n)
+		}
+	})
+	return nil, nil
+}
+
+// checkStmts checks the use of Catchers in a list of statements.
+func checkStmts(pass *analysis.Pass, list []ast.Stmt) {
+	handled := map[types.Object]bool{}
+	for i, s := range list {
+		if obj, call := catcherDecl(pass, s); obj != nil {
+			if i+1 >= len(list) || !isDeferredHandle(pass, list[i+1], obj) {
+				pass.Reportf(call.Pos(), "result of errc.%s must be followed by defer %s.Handle()",
+					calleeName(call), obj.Name())
+			}
+			delete(handled, obj)
+			continue
+		}
+		if es, ok := s.(*ast.ExprStmt); ok {
+			if obj, name := catcherMethod(pass, es.X); name == "Handle" {
+				pass.Reportf(es.Pos(), "call to Handle must be deferred")
+				if obj != nil {
+					handled[obj] = true
+				}
+				continue
+			}
+		}
+		if len(handled) == 0 {
+			continue
+		}
+		ast.Inspect(s, func(n ast.Node) bool {
+			if obj, name := catcherMethod(pass, n); obj != nil && handled[obj] && name != "Handle" {
+				pass.Reportf(n.Pos(), "use of %s after Handle", obj.Name())
+			}
+			return true
+		})
+	}
+}
+
+// catcherDecl reports the variable to which s assigns the result of a call to
+// one of the Catch functions.
+func catcherDecl(pass *analysis.Pass, s ast.Stmt) (types.Object, *ast.CallExpr) {
+	var lhs, rhs []ast.Expr
+	switch s := s.(type) {
+	case *ast.AssignStmt:
+		lhs, rhs = s.Lhs, s.Rhs
+	case *ast.DeclStmt:
+		d, ok := s.Decl.(*ast.GenDecl)
+		if !ok || len(d.Specs) != 1 {
+			return nil, nil
+		}
+		vs, ok := d.Specs[0].(*ast.ValueSpec)
+		if !ok {
+			return nil, nil
+		}
+		for _, n := range vs.Names {
+			lhs = append(lhs, n)
+		}
+		rhs = vs.Values
+	}
+	if len(lhs) != 1 || len(rhs) != 1 {
+		return nil, nil
+	}
+	call, ok := astutil.Unparen(rhs[0]).(*ast.CallExpr)
+	if !ok || !catchFuncs[calleeName(call)] || !isErrcFunc(pass, call) {
+		return nil, nil
+	}
+	id, ok := lhs[0].(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	return pass.TypesInfo.ObjectOf(id), call
+}
+
+// isDeferredHandle reports whether s is defer obj.Handle().
+func isDeferredHandle(pass *analysis.Pass, s ast.Stmt, obj types.Object) bool {
+	d, ok := s.(*ast.DeferStmt)
+	if !ok {
+		return false
+	}
+	o, name := catcherMethod(pass, d.Call)
+	return name == "Handle" && o == obj
+}
+
+// catcherMethod reports the receiver variable and method name if n is a call
+// of a method of errc.Catcher. The returned object is nil if the receiver is
+// not a variable.
+func catcherMethod(pass *analysis.Pass, n ast.Node) (types.Object, string) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return nil, ""
+	}
+	sel, ok := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	fn, ok := pass.TypesInfo.ObjectOf(sel.Sel).(*types.Func)
+	if !ok {
+		return nil, ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil || !isCatcher(recv.Type()) {
+		return nil, ""
+	}
+	var obj types.Object
+	if id, ok := astutil.Unparen(sel.X).(*ast.Ident); ok {
+		obj = pass.TypesInfo.ObjectOf(id)
+	}
+	return obj, fn.Name()
+}
+
+// checkParams reports parameters of type errc.Catcher.
+func checkParams(pass *analysis.Pass, ft *ast.FuncType) {
+	if ft.Params == nil {
+		return
+	}
+	for _, f := range ft.Params.List {
+		if t := pass.TypesInfo.TypeOf(f.Type); t != nil && isCatcherValue(t) {
+			pass.Reportf(f.Type.Pos(), "errc.Catcher passed by value; use *errc.Catcher")
+		}
+	}
+}
+
+// checkArgs reports arguments of type errc.Catcher.
+func checkArgs(pass *analysis.Pass, call *ast.CallExpr) {
+	for _, a := range call.Args {
+		if t := pass.TypesInfo.TypeOf(a); t != nil && isCatcherValue(t) {
+			pass.Reportf(a.Pos(), "errc.Catcher passed by value; pass a pointer instead")
+		}
+	}
+}
+
+func calleeName(call *ast.CallExpr) string {
+	switch f := astutil.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		return f.Sel.Name
+	}
+	return ""
+}
+
+// isErrcFunc reports whether call calls a function of package errc.
+func isErrcFunc(pass *analysis.Pass, call *ast.CallExpr) bool {
+	var id *ast.Ident
+	switch f := astutil.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	}
+	fn, ok := pass.TypesInfo.ObjectOf(id).(*types.Func)
+	return ok && fn.Pkg() != nil && isErrcPkg(fn.Pkg().Path())
+}
+
+// isCatcher reports whether t is errc.Catcher or *errc.Catcher.
+func isCatcher(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	return isCatcherValue(t)
+}
+
+// isCatcherValue reports whether t is errc.Catcher.
+func isCatcherValue(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := n.Obj()
+	return obj.Name() == "Catcher" && obj.Pkg() != nil && isErrcPkg(obj.Pkg().Path())
+}
+
+// isErrcPkg reports whether path is the import path of package errc, possibly
+// vendored.
+func isErrcPkg(path string) bool {
+	return path == errcPath || strings.HasSuffix(path, "/vendor/"+errcPath)
+}
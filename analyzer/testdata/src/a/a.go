@@ -0,0 +1,63 @@
+package a
+
+import "github.com/mpvl/errc"
+
+func good() (err error) {
+	e := errc.Catch(&err)
+	defer e.Handle()
+	e.Must(nil)
+	s := e.Scope()
+	s.Must(nil)
+	return nil
+}
+
+func goodVar() (err error) {
+	var e = errc.CatchLite(&err)
+	defer e.Handle()
+	helper(&e)
+	return nil
+}
+
+func noDefer() (err error) {
+	e := errc.Catch(&err) // want `result of errc.Catch must be followed by defer e.Handle\(\)`
+	e.Must(nil)
+	defer e.Handle()
+	return nil
+}
+
+func noDeferPooled() (err error) {
+	e := errc.Pooled(&err) // want `result of errc.Pooled must be followed by defer e.Handle\(\)`
+	e.Must(nil)
+	return nil
+}
+
+func goodPooled() (err error) {
+	e := errc.Pooled(&err)
+	defer e.Handle()
+	e.Must(nil)
+	return nil
+}
+
+func notDeferred() (err error) {
+	e := errc.Catch(&err) // want `result of errc.Catch must be followed by defer e.Handle\(\)`
+	e.Handle()            // want `call to Handle must be deferred`
+	e.Must(nil)           // want `use of e after Handle`
+	func() {
+		e.Defer(nil) // want `use of e after Handle`
+	}()
+	return nil
+}
+
+func byValue(e errc.Catcher) { // want `errc.Catcher passed by value; use \*errc.Catcher`
+}
+
+func passByValue() (err error) {
+	e := errc.Catch(&err)
+	defer e.Handle()
+	use(e) // want `errc.Catcher passed by value; pass a pointer instead`
+	return nil
+}
+
+func helper(e *errc.Catcher) {}
+
+func use(x interface{}) {}
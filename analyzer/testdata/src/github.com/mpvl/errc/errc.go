@@ -0,0 +1,20 @@
+// Package errc is a stub of package errc for testing the analyzer.
+package errc
+
+type Handler interface{}
+
+type Catcher struct{ err *error }
+
+func Catch(err *error, h ...Handler) Catcher { return Catcher{err} }
+
+func CatchLite(err *error, h ...Handler) Catcher { return Catcher{err} }
+
+func Pooled(err *error, h ...Handler) *Catcher { return &Catcher{err} }
+
+func (e *Catcher) Handle() {}
+
+func (e *Catcher) Must(err error, h ...Handler) {}
+
+func (e *Catcher) Defer(x interface{}, h ...Handler) {}
+
+func (e *Catcher) Scope() *Catcher { return e }
@@ -0,0 +1,43 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+// A Bucket is a Handler that files each error it handles under its name.
+// The errors filed in each bucket can be retrieved with Buckets. A Bucket
+// passes errors on unmodified, so it is typically combined with other
+// handlers. For instance,
+//
+//     e.Must(err, errc.Bucket("network"), errc.Discard)
+//
+// records a network failure and continues as if the error did not occur.
+type Bucket string
+
+// Handle implements Handler.
+func (b Bucket) Handle(s State, err error) error {
+	if st, ok := s.(*state); ok {
+		x := st.owner().ext()
+		if x.buckets == nil {
+			x.buckets = map[string][]error{}
+		}
+		x.buckets[string(b)] = append(x.buckets[string(b)], err)
+	}
+	return err
+}
+
+// Buckets reports the errors that were filed by Bucket handlers, keyed by
+// bucket name. It is typically called after Handle to report aggregates, as in
+// "3 network failures, 12 validation failures". The result is a copy that the
+// caller may modify.
+func (e *Catcher) Buckets() map[string][]error {
+	buckets := e.owner().extOrZero().buckets
+	if buckets == nil {
+		return nil
+	}
+	m := make(map[string][]error, len(buckets))
+	for name, errs := range buckets {
+		m[name] = append([]error(nil), errs...)
+	}
+	return m
+}
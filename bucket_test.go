@@ -0,0 +1,45 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBucket(t *testing.T) {
+	errNet := errors.New("network")
+	errVal := errors.New("validation")
+
+	var e Catcher
+	err := func() (err error) {
+		e = Catch(&err)
+		defer e.Handle()
+		for i := 0; i < 3; i++ {
+			e.Must(errNet, Bucket("network"), Discard)
+		}
+		e.Must(errVal, Bucket("validation"), Discard)
+		e.Defer(func() error { return errVal }, Bucket("validation"))
+		return nil
+	}()
+	buckets := e.Buckets()
+	if err != errVal {
+		t.Errorf("err: got %v; want %v", err, errVal)
+	}
+	if got := len(buckets["network"]); got != 3 {
+		t.Errorf("network: got %d; want 3", got)
+	}
+	if got := len(buckets["validation"]); got != 2 {
+		t.Errorf("validation: got %d; want 2", got)
+	}
+	if got := len(buckets); got != 2 {
+		t.Errorf("buckets: got %d; want 2", got)
+	}
+	delete(buckets, "network")
+	buckets["validation"][0] = nil
+	if got := e.Buckets(); len(got["network"]) != 3 || got["validation"][0] != errVal {
+		t.Errorf("modifying the result of Buckets changed the Catcher: got %v", got)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TB is the subset of testing.TB used by CatchT.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// CatchT returns a Catcher that fails the test t if it catches an error,
+// instead of recording the error in an error variable:
+//
+//     func TestCopy(t *testing.T) {
+//         e := errc.CatchT(t)
+//         defer e.Handle()
+//
+//         r, err := os.Open("testdata/src")
+//         e.Must(err)
+//         e.Defer(r.Close)
+//         ...
+//     }
+//
+// The failure message includes the error and the stack trace of the point at
+// which it was detected by Must or, for panics, at which it occurred. A test
+// that fails due to an error detected by Must or a deferred function is stopped
+// with Fatalf; a panic is reported with Errorf before it is resumed.
+func CatchT(t TB, h ...Handler) Catcher {
+	ec := Catch(new(error), h...)
+	ec.ext().t = t
+	return ec
+}
+
+// reportT fails the test of a Catcher created with CatchT if it caught an error.
+func reportT(e *Catcher) {
+	err := *e.err
+	if err == nil {
+		return
+	}
+	t := e.x.t
+	t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, "errc: %v", err)
+	stack := Stack(err)
+	if stack == nil {
+		stack = e.x.tStack
+	}
+	for _, f := range stack {
+		fmt.Fprintf(&b, "\n\t%s\n\t\t%s:%d", f.Function, f.File, f.Line)
+	}
+	if e.inPanic {
+		t.Errorf("%s", b.String())
+	} else {
+		t.Fatalf("%s", b.String())
+	}
+}
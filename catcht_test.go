@@ -0,0 +1,74 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeT records the failures of a test.
+type fakeT struct {
+	errors []string
+	fatal  bool
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	t.fatal = true
+}
+
+func TestCatchT(t *testing.T) {
+	ft := &fakeT{}
+	func() {
+		e := CatchT(ft)
+		defer e.Handle()
+		e.Must(nil)
+	}()
+	if len(ft.errors) != 0 {
+		t.Errorf("got failures %q; want none", ft.errors)
+	}
+
+	closed := false
+	func() {
+		e := CatchT(ft)
+		defer e.Handle()
+		e.Defer(func() { closed = true })
+		e.Must(errors.New("foo"), Wrap("bar"))
+	}()
+	if !closed {
+		t.Error("deferred function not run")
+	}
+	if len(ft.errors) != 1 || !ft.fatal {
+		t.Fatalf("got failures %q (fatal: %v); want 1 fatal failure", ft.errors, ft.fatal)
+	}
+	if msg := ft.errors[0]; !strings.HasPrefix(msg, "errc: bar: foo\n") || !strings.Contains(msg, "catcht_test.go") {
+		t.Errorf("got %q; want error and stack trace", msg)
+	}
+}
+
+func TestCatchTPanic(t *testing.T) {
+	ft := &fakeT{}
+	func() {
+		defer func() { recover() }()
+		e := CatchT(ft)
+		defer e.Handle()
+		panic("boom")
+	}()
+	if len(ft.errors) != 1 || ft.fatal {
+		t.Fatalf("got failures %q (fatal: %v); want 1 non-fatal failure", ft.errors, ft.fatal)
+	}
+	if msg := ft.errors[0]; !strings.HasPrefix(msg, "errc: errd: paniced: boom\n") {
+		t.Errorf("got %q; want panic and stack trace", msg)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "errors"
+
+var (
+	// Temporary is a Handler that marks errors as temporary. The resulting
+	// error has a method Temporary that returns true, which is the convention
+	// used by package net and many retry libraries, and wraps the original
+	// error.
+	//
+	//     e.Must(err, errc.Temporary)
+	Temporary Handler = HandlerFunc(temporary)
+
+	// Permanent is a Handler that marks errors as permanent. The resulting
+	// error has a method Temporary that returns false, overriding any
+	// classification of the errors it wraps.
+	Permanent Handler = HandlerFunc(permanent)
+)
+
+func temporary(s State, err error) error { return &classError{err, true} }
+func permanent(s State, err error) error { return &classError{err, false} }
+
+type classError struct {
+	err       error
+	temporary bool
+}
+
+func (e *classError) Error() string   { return e.err.Error() }
+func (e *classError) Unwrap() error   { return e.err }
+func (e *classError) Temporary() bool { return e.temporary }
+
+// IsTemporary reports whether the first error in err's chain that has a method
+// Temporary reports true.
+func IsTemporary(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
+
+// A Coder is an error with a code, such as an HTTP status code or an RPC code.
+// Errors annotated with WithCode implement Coder.
+type Coder interface {
+	error
+	Code() int
+}
+
+// WithCode returns a Handler that annotates errors with the given code. The
+// resulting error implements Coder and wraps the original error.
+//
+//     e.Must(err, errc.WithCode(http.StatusConflict))
+func WithCode(code int) Handler {
+	return withCode(code)
+}
+
+type withCode int
+
+func (c withCode) Handle(s State, err error) error {
+	return &codeError{err, int(c)}
+}
+
+type codeError struct {
+	err  error
+	code int
+}
+
+func (e *codeError) Error() string { return e.err.Error() }
+func (e *codeError) Unwrap() error { return e.err }
+func (e *codeError) Code() int     { return e.code }
+
+// Code returns the code of the first error in err's chain that implements
+// Coder. It reports false if there is no such error.
+func Code(err error) (code int, ok bool) {
+	var c Coder
+	if errors.As(err, &c) {
+		return c.Code(), true
+	}
+	return 0, false
+}
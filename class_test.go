@@ -0,0 +1,75 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	errFoo := errors.New("foo")
+	testCases := []struct {
+		desc      string
+		h         []Handler
+		err       error
+		temporary bool
+		code      int
+		hasCode   bool
+	}{{
+		desc: "none",
+		err:  errFoo,
+	}, {
+		desc:      "temporary",
+		h:         []Handler{Temporary},
+		err:       errFoo,
+		temporary: true,
+	}, {
+		desc:      "inherited",
+		err:       context.DeadlineExceeded,
+		temporary: true,
+	}, {
+		desc: "permanent overrides",
+		h:    []Handler{Permanent},
+		err:  context.DeadlineExceeded,
+	}, {
+		desc:      "last classification wins",
+		h:         []Handler{Permanent, Temporary},
+		err:       errFoo,
+		temporary: true,
+	}, {
+		desc:      "code",
+		h:         []Handler{WithCode(409), Temporary},
+		err:       errFoo,
+		temporary: true,
+		code:      409,
+		hasCode:   true,
+	}, {
+		desc:    "outer code",
+		h:       []Handler{WithCode(1), WithCode(2)},
+		err:     errFoo,
+		code:    2,
+		hasCode: true,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := Run(func(e *Catcher) error {
+				e.Must(tc.err, tc.h...)
+				return nil
+			})
+			if !errors.Is(err, tc.err) || err.Error() != tc.err.Error() {
+				t.Errorf("got %v; want wrapped %v", err, tc.err)
+			}
+			if got := IsTemporary(err); got != tc.temporary {
+				t.Errorf("IsTemporary: got %v; want %v", got, tc.temporary)
+			}
+			code, ok := Code(err)
+			if code != tc.code || ok != tc.hasCode {
+				t.Errorf("Code: got %d, %v; want %d, %v", code, ok, tc.code, tc.hasCode)
+			}
+		})
+	}
+}
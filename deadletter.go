@@ -0,0 +1,85 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Annotate adds a key-value pair describing the operation guarded by e, such
+// as the id of the item being processed. Annotations are included in dead
+// letters. See DeadLetters.
+func (e *Catcher) Annotate(key string, value interface{}) {
+	x := e.owner().ext()
+	x.annotations = append(x.annotations, Field{key, value})
+}
+
+// A DeadLetter records a failed operation so that it can be replayed.
+type DeadLetter struct {
+	// Error is the final error of the operation.
+	Error string `json:"error"`
+
+	// Panic reports whether the operation panicked.
+	Panic bool `json:"panic"`
+
+	// Annotations holds the annotations added with Annotate, followed by the
+	// fields of the error. Later values take precedence.
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+
+	// Time is the time at which the failure was recorded.
+	Time time.Time `json:"time"`
+}
+
+// A DeadLetterWriter receives the DeadLetters of failed operations. It could,
+// for instance, write them to a file or queue.
+type DeadLetterWriter interface {
+	// WriteDeadLetter writes l. It is called from Handle and must not
+	// retain l.
+	WriteDeadLetter(l *DeadLetter) error
+}
+
+// DeadLetters returns an Option that causes a DeadLetter to be written to w
+// at the end of Handle if the Catcher recorded an error. Errors returned by w
+// are ignored.
+func DeadLetters(w DeadLetterWriter) Option {
+	return option(func(c *core) { c.ext().deadLetters = w })
+}
+
+func writeDeadLetter(e *Catcher, err error) {
+	l := &DeadLetter{
+		Error: err.Error(),
+		Panic: e.inPanic,
+		Time:  time.Now(),
+	}
+	a := e.x.annotations
+	fields := append(a[:len(a):len(a)], Fields(err)...)
+	if len(fields) > 0 {
+		l.Annotations = map[string]interface{}{}
+		for _, f := range fields {
+			l.Annotations[f.Key] = f.Value
+		}
+	}
+	e.x.deadLetters.WriteDeadLetter(l)
+}
+
+// JSONDeadLetters returns a DeadLetterWriter that writes each DeadLetter to w
+// as a single line of JSON. It is safe for concurrent use.
+func JSONDeadLetters(w io.Writer) DeadLetterWriter {
+	return &jsonDeadLetters{enc: json.NewEncoder(w)}
+}
+
+type jsonDeadLetters struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (w *jsonDeadLetters) WriteDeadLetter(l *DeadLetter) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(l)
+}
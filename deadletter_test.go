@@ -0,0 +1,55 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDeadLetters(t *testing.T) {
+	testCases := []struct {
+		desc string
+		err  error
+		want string
+	}{{
+		desc: "success",
+	}, {
+		desc: "failure",
+		err:  WithFields(errors.New("foo"), Field{"attempt", 2}),
+		want: `{"error":"foo","panic":false,"annotations":{"attempt":2,"item":"x1"}}`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			func() (err error) {
+				e := Catch(&err, DeadLetters(JSONDeadLetters(buf)))
+				defer e.Handle()
+				e.Annotate("item", "x1")
+				e.Must(tc.err)
+				return nil
+			}()
+			if tc.want == "" {
+				if buf.Len() > 0 {
+					t.Errorf("got %q; want no dead letter", buf)
+				}
+				return
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			delete(got, "time")
+			var want map[string]interface{}
+			json.Unmarshal([]byte(tc.want), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v; want %v", got, want)
+			}
+		})
+	}
+}
@@ -5,47 +5,120 @@
 package errc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"runtime"
+	"strings"
 	"sync"
 )
 
-// A closerWithError is an io.Closer that also implements CloseWithError.
-type closerWithError interface {
+// A CloserWithError is an io.Closer that also implements CloseWithError, like
+// io.PipeWriter.
+type CloserWithError interface {
 	io.Closer
 	CloseWithError(error) error
 }
 
+// A contextCloser is closed with a context that bounds the shutdown.
+type contextCloser interface {
+	CloseContext(ctx context.Context) error
+}
+
+// A shutdowner is shut down with a context that bounds the shutdown, like
+// http.Server.
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
 type deferData struct {
-	x interface{}
-	f deferFunc
+	x  interface{}
+	f  deferFunc
+	pc uintptr // location of the call to Defer, if callers are recorded
 }
 
-// TODO: DeferFunc is much faster than Defer, as it avoids an allocation in many
-// cases. Add it back if users need this performance.
+// push registers a call to f with argument x.
+func (e *Catcher) push(x interface{}, f deferFunc) {
+	d := deferData{x: x, f: f}
+	if e.recordCallers {
+		d.pc = callerPC()
+	}
+	e.deferred = append(e.deferred, d)
+	e.stats.DefersRegistered++
+}
 
-// A DeferFunc is used to call cleanup code for x at defer time.
+// A deferFunc is used to call cleanup code for x at defer time.
 type deferFunc func(s State, x interface{}) error
 
-// DeferFunc calls f at the end of a Run with x as its argument.
-//
-// If f returns an error it will be passed to the error handlers.
-//
-// DeferFunc can be used to avoid the allocation typically incurred
-// with Defer.
+// deferFunc registers a call to f with x as its argument. If f returns an error
+// it will be passed to the handlers h.
 func (e *Catcher) deferFunc(x interface{}, f deferFunc, h ...Handler) {
 	if f == nil {
 		panic(errNilFunc)
 	}
+	if e.parent != nil && !e.registered {
+		register(e)
+	}
+	if e.safe && deferSafe(e, x, f, h) {
+		return
+	}
+	e.deferred = appendHandlers(e.deferred, h)
+	e.push(x, f)
+}
+
+// appendHandlers appends entries for the handlers h of a deferred function to
+// d in reverse order, expanding chains.
+func appendHandlers(d []deferData, h []Handler) []deferData {
 	for i := len(h) - 1; i >= 0; i-- {
-		e.deferred = append(e.deferred, deferData{h[i], nil})
+		if c, ok := h[i].(chain); ok {
+			d = appendHandlers(d, c)
+			continue
+		}
+		d = append(d, deferData{x: h[i]})
 	}
-	e.deferred = append(e.deferred, deferData{x, f})
+	return d
 }
 
 var errNilFunc = errors.New("errd: nil DeferFunc")
 
+// A runner is a Handler that controls how a deferred function is run. Runners
+// passed to Defer are applied in order, the first one being the outermost. A
+// runner passes s, or a State derived from it, to f.
+type runner interface {
+	Handler
+	run(s State, label string, f func(s State) error) error
+}
+
+// runDefer runs the deferred function d, which was just removed from the top
+// of the defer stack, applying any runners among its handlers.
+func runDefer(e *Catcher, d deferData) error {
+	st := (*state)(e)
+	i, hasRunner := len(e.deferred), false
+	for ; i > 0 && e.deferred[i-1].f == nil; i-- {
+		if _, ok := e.deferred[i-1].x.(runner); ok {
+			hasRunner = true
+		}
+	}
+	if !hasRunner {
+		return d.f(st, d.x)
+	}
+	// The entry of d is still held by the underlying array.
+	label := deferLabel(e.deferred[:len(e.deferred)+1])
+	f := func(s State) error { return d.f(s, d.x) }
+	for _, h := range e.deferred[i:] {
+		if r, ok := h.x.(runner); ok {
+			f = wrapRun(r, label, f)
+		}
+	}
+	return f(st)
+}
+
+func wrapRun(r runner, label string, f func(s State) error) func(s State) error {
+	return func(s State) error { return r.run(s, label, f) }
+}
+
 var (
 	// Close calls x.Close().
 	close deferFunc = closeFunc
@@ -62,13 +135,18 @@ func closeFunc(s State, x interface{}) error {
 }
 
 func closeWithErrorFunc(s State, x interface{}) error {
-	c := x.(closerWithError)
+	c := x.(CloserWithError)
 	if err := s.Err(); err != nil {
 		return c.CloseWithError(err)
 	}
 	return c.Close()
 }
 
+func cancelFunc(s State, x interface{}) error {
+	x.(context.CancelFunc)()
+	return nil
+}
+
 func unlockFunc(s State, x interface{}) error {
 	x.(sync.Locker).Unlock()
 	return nil
@@ -96,20 +174,65 @@ func stateErrorFunc(s State, x interface{}) error {
 	return x.(func(s State) error)(s)
 }
 
+func contextErrorFunc(s State, x interface{}) error {
+	return x.(func(context.Context) error)(shutdownContext(s))
+}
+
+func closeContextFunc(s State, x interface{}) error {
+	return x.(contextCloser).CloseContext(shutdownContext(s))
+}
+
+func shutdownFunc(s State, x interface{}) error {
+	return x.(shutdowner).Shutdown(shutdownContext(s))
+}
+
+// ShutdownContext returns an Option that sets the context passed to deferred
+// functions that take a context. See Defer. By default, the context passed to
+// CatchContext is used without its cancelation and deadline, so that the
+// shutdown is not aborted if the operation itself was canceled. If there is no
+// such context, context.Background() is used.
+func ShutdownContext(ctx context.Context) Option {
+	return option(func(c *core) { c.ext().shutdownCtx = ctx })
+}
+
+func shutdownContext(s State) context.Context {
+	switch st := s.(type) {
+	case *state:
+		if ctx := st.extOrZero().shutdownCtx; ctx != nil {
+			return ctx
+		}
+	case *snapshot:
+		if st.shutdownCtx != nil {
+			return st.shutdownCtx
+		}
+	}
+	return context.WithoutCancel(s.Context())
+}
+
 // Defer defers a call to x, which may be a function of the form:
 //    - func()
 //    - func() error
 //    - func(error)
 //    - func(error) error
 //    - func(State) error
-// An error returned by any of these functions is passed to the error handlers.
+//    - func(context.Context) error
+//    - context.CancelFunc
+// or a value implementing one of the following methods, in order of
+// precedence:
+//    - CloseWithError(error) error, in addition to Close
+//    - CloseContext(context.Context) error
+//    - Shutdown(context.Context) error
+//    - Close() error
+// Functions taking an error are passed the current error, as reported by
+// State.Err. Functions and methods taking a context are passed the context set
+// with ShutdownContext. An error returned by any of these functions is passed
+// to the error handlers. Defer panics for any other type of x.
 //
-// Performance-sensitive applications should use DeferFunc.
+// Performance-sensitive applications should use DeferClose, DeferFunc,
+// DeferCloseWithError, or DeferCancel, which avoid the type switch and, for
+// method values, the allocation typically incurred with Defer.
 func (e *Catcher) Defer(x interface{}, h ...Handler) {
 	if x != nil {
-		for i := len(h) - 1; i >= 0; i-- {
-			e.deferred = append(e.deferred, deferData{h[i], nil})
-		}
 		var f deferFunc
 		switch x.(type) {
 		case func():
@@ -122,25 +245,198 @@ func (e *Catcher) Defer(x interface{}, h ...Handler) {
 			f = errorErrorFunc
 		case func(s State) error:
 			f = stateErrorFunc
+		case func(context.Context) error:
+			f = contextErrorFunc
+		case context.CancelFunc:
+			f = cancelFunc
+		case CloserWithError:
+			f = closeWithErrorFunc
+		case contextCloser:
+			f = closeContextFunc
+		case shutdowner:
+			f = shutdownFunc
+		case io.Closer:
+			f = closeFunc
 		default:
 			panic(fmt.Errorf(notSupported, x))
 		}
-		e.deferred = append(e.deferred, deferData{x, f})
+		e.deferFunc(x, f, h...)
+	}
+}
+
+// DeferClose defers a call to c.Close. An error returned by Close is passed to
+// the error handlers.
+func (e *Catcher) DeferClose(c io.Closer, h ...Handler) {
+	if c != nil {
+		e.deferFunc(c, closeFunc, h...)
+	}
+}
+
+// DeferCloseWithError defers a call to c.CloseWithError with the current error
+// or, if there is no error, to c.Close. An error returned by either method is
+// passed to the error handlers.
+func (e *Catcher) DeferCloseWithError(c CloserWithError, h ...Handler) {
+	if c != nil {
+		e.deferFunc(c, closeWithErrorFunc, h...)
+	}
+}
+
+// DeferFunc defers a call to f. An error returned by f is passed to the error
+// handlers.
+func (e *Catcher) DeferFunc(f func() error, h ...Handler) {
+	if f != nil {
+		e.deferFunc(f, voidErrorFunc, h...)
+	}
+}
+
+// DeferCancel defers a call to cancel.
+func (e *Catcher) DeferCancel(cancel context.CancelFunc, h ...Handler) {
+	if cancel != nil {
+		e.deferFunc(cancel, cancelFunc, h...)
 	}
 }
 
 const notSupported = "errd: type %T not supported by Defer"
 
-// TODO
+// A Label is a Handler that names the deferred function with which it is
+// passed. Labels are reported by State.Pending. A Label passes errors on
+// unmodified.
+//
+//     e.Defer(w.CloseWithError, errc.Label("upload"))
+type Label string
+
+// Handle implements Handler.
+func (l Label) Handle(s State, err error) error { return err }
+
+// pending returns the labels of the deferred functions in d in the order in
+// which they will be run.
+func pending(d []deferData) []string {
+	labels := []string{}
+	for i := len(d) - 1; i >= 0; i-- {
+		switch s := scopeOf(d[i]); {
+		case s != nil:
+			labels = append(labels, pending(s.deferred)...)
+		case d[i].f != nil:
+			labels = append(labels, deferLabel(d[:i+1]))
+		}
+	}
+	return labels
+}
+
+// numDeferred returns the number of deferred functions in d, counting the
+// deferred functions of scopes instead of their entries.
+func numDeferred(d []deferData) (n int) {
+	for _, x := range d {
+		switch s := scopeOf(x); {
+		case s != nil:
+			n += numDeferred(s.deferred)
+		case x.f != nil:
+			n++
+		}
+	}
+	return n
+}
+
+// deferLabel returns the label of the last entry in d, which must be a deferred
+// function. It uses the name passed with a Label handler, if any, or the name
+// of the function or type of the deferred value otherwise.
+func deferLabel(d []deferData) string {
+	i := len(d) - 1
+	for j := i - 1; j >= 0 && d[j].f == nil; j-- {
+		if l, ok := d[j].x.(Label); ok {
+			return string(l)
+		}
+	}
+	x := d[i].x
+	if v := reflect.ValueOf(x); v.Kind() == reflect.Func {
+		if f := runtime.FuncForPC(v.Pointer()); f != nil {
+			return strings.TrimSuffix(f.Name(), "-fm")
+		}
+	}
+	return fmt.Sprintf("%T", x)
+}
+
+// DeferNamed is like Defer, but names the deferred function, as if Label(name)
+// were passed as the first of the handlers. The name can be used to cancel the
+// deferred function with CancelDefer or to run it early with Promote. This
+// supports the common pattern of registering a rollback that is canceled once
+// a transaction commits:
 //
-// // DeferScope calls f and calls all defers that were added within that call
-// // after it completes. An error that occurs in f is handled as if the error
-// // occurred in the caller. This includes errors in defer. DeferScope is used to
-// // force early cleanup of defers within a tight loop.
-// func (e *E) DeferScope(f func()) {
-// 	localDefer := len(e.deferred)
-// 	f()
-// 	doDefers(e, localDefer)
-
-// 	// TODO: bail if we detect an error.
-// }
+//     e.DeferNamed("rollback", tx.Rollback)
+//     ...
+//     e.Must(tx.Commit())
+//     e.CancelDefer("rollback")
+func (e *Catcher) DeferNamed(name string, x interface{}, h ...Handler) {
+	e.Defer(x, append([]Handler{Label(name)}, h...)...)
+}
+
+// CancelDefer removes the most recently registered deferred function labeled
+// name, so that it is not run. It reports whether such a function was found.
+func (e *Catcher) CancelDefer(name string) bool {
+	lo, hi := findLabeled(e.deferred, name)
+	if lo < 0 {
+		return false
+	}
+	e.deferred = removeDefers(e.deferred, lo, hi)
+	return true
+}
+
+// Promote runs the most recently registered deferred function labeled name
+// now, rather than when the function returns. An error returned by the deferred
+// function is handled as by Flush. Promote reports whether such a function was
+// found.
+func (e *Catcher) Promote(name string) bool {
+	lo, hi := findLabeled(e.deferred, name)
+	if lo < 0 {
+		return false
+	}
+	var buf [bufSize]deferData
+	entries := append(buf[:0], e.deferred[lo:hi]...)
+	e.deferred = removeDefers(e.deferred, lo, hi)
+	barrier := len(e.deferred)
+	e.deferred = append(e.deferred, entries...)
+	failed := doDefers(e, barrier)
+	if failed {
+		e.failed = true
+	}
+	switch {
+	case !failed, e.collect:
+	case e.lite:
+		failLite(e)
+	default:
+		panic(errOurPanic)
+	}
+	return true
+}
+
+// findLabeled returns the range of entries in d of the most recently
+// registered function with the given label, including its handlers, or -1 if
+// there is no such function.
+func findLabeled(d []deferData, label string) (lo, hi int) {
+	for i := len(d) - 1; i >= 0; i-- {
+		if d[i].f == nil {
+			continue
+		}
+		j := i
+		found := false
+		for ; j > 0 && d[j-1].f == nil; j-- {
+			if l, ok := d[j-1].x.(Label); ok && string(l) == label {
+				found = true
+			}
+		}
+		if found {
+			return j, i + 1
+		}
+		i = j
+	}
+	return -1, -1
+}
+
+// removeDefers removes the entries lo through hi-1 from d.
+func removeDefers(d []deferData, lo, hi int) []deferData {
+	n := copy(d[lo:], d[hi:])
+	for i := lo + n; i < len(d); i++ {
+		d[i] = deferData{}
+	}
+	return d[:lo+n]
+}
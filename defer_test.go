@@ -5,7 +5,9 @@
 package errc
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -181,3 +183,215 @@ func BenchmarkDeferClosure(b *testing.B) {
 		e.deferred = e.deferred[:0]
 	}
 }
+
+func TestPending(t *testing.T) {
+	var got, gotMust []string
+	x := &closer{new(string)}
+	func() {
+		var err error
+		e := Catch(&err)
+		defer e.Handle()
+		e.Defer(x.Close)
+		e.deferFunc(x, closeFunc)
+		e.Defer(func(s State) error {
+			got = s.Pending()
+			return nil
+		}, Label("pending"))
+		e.Defer(func() {}, Label("first"), identity)
+		e.Must(errors.New("err"), HandlerFunc(func(s State, err error) error {
+			gotMust = s.Pending()
+			return nil
+		}))
+	}()
+	want := []string{
+		"*errc.closer",
+		"github.com/mpvl/errc.(*closer).Close",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	want = append([]string{"first", "pending"}, want...)
+	if strings.Join(gotMust, ",") != strings.Join(want, ",") {
+		t.Errorf("Must: got %q; want %q", gotMust, want)
+	}
+}
+
+type ctxKey struct{}
+
+type ctxCloser struct{ v *string }
+
+func (c *ctxCloser) CloseContext(ctx context.Context) error {
+	*c.v += ":CloseContext:" + ctx.Value(ctxKey{}).(string)
+	return nil
+}
+
+type ctxShutdowner struct{ v *string }
+
+func (c *ctxShutdowner) Shutdown(ctx context.Context) error {
+	*c.v += ":Shutdown:" + ctx.Value(ctxKey{}).(string)
+	return nil
+}
+
+func TestDeferContext(t *testing.T) {
+	var result string
+	ctx := context.WithValue(context.Background(), ctxKey{}, "ctx")
+	func() {
+		var err error
+		e := Catch(&err, ShutdownContext(ctx))
+		defer e.Handle()
+		e.Defer(&ctxCloser{&result})
+		e.Defer(&ctxShutdowner{&result})
+		e.Defer(func(ctx context.Context) error {
+			result += ":func:" + ctx.Value(ctxKey{}).(string)
+			return nil
+		})
+	}()
+	if want := ":func:ctx:Shutdown:ctx:CloseContext:ctx"; result != want {
+		t.Errorf("got %q; want %q", result, want)
+	}
+}
+
+func TestDeferContextDefault(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "ctx"))
+	cancel()
+	var got context.Context
+	func() {
+		var err error
+		e := CatchContext(ctx, &err)
+		defer e.Handle()
+		e.Defer(func(ctx context.Context) error {
+			got = ctx
+			return nil
+		})
+	}()
+	if got.Value(ctxKey{}) != "ctx" {
+		t.Errorf("context does not carry values of the Catcher's context")
+	}
+	if got.Err() != nil {
+		t.Errorf("got %v; want shutdown context not to be canceled", got.Err())
+	}
+}
+
+func TestDeferTyped(t *testing.T) {
+	var closed, closedWithError, result string
+	var err error
+	func() {
+		e := Catch(&err)
+		defer e.Handle()
+		e.DeferClose(nil)
+		e.DeferCloseWithError(nil)
+		e.DeferFunc(nil)
+		e.DeferCancel(nil)
+
+		e.DeferClose(&closer{&closed})
+		e.DeferFunc(func() error {
+			result += ":Func"
+			return nil
+		})
+		e.DeferCancel(func() { result += ":Cancel" })
+		e.DeferCloseWithError(&closerError{&closedWithError}, Discard)
+	}()
+	if err != nil {
+		t.Errorf("got %v; want nil", err)
+	}
+	if want := ":Cancel:Func"; result != want {
+		t.Errorf("got %q; want %q", result, want)
+	}
+	if closed != "Close" {
+		t.Errorf("DeferClose: got %q; want %q", closed, "Close")
+	}
+	if closedWithError != "CloseNil" {
+		t.Errorf("DeferCloseWithError: got %q; want %q", closedWithError, "CloseNil")
+	}
+}
+
+func TestDeferObjects(t *testing.T) {
+	var closed, result string
+	var err error
+	func() {
+		e := Catch(&err)
+		defer e.Handle()
+		// A CloserWithError takes precedence over io.Closer.
+		e.Defer(&closerError{&closed}, Discard)
+		e.Defer(context.CancelFunc(func() { result += ":Cancel" }))
+		e.Must(errors.New("fail"))
+	}()
+	if err == nil || err.Error() != "fail" {
+		t.Errorf("got %v; want fail", err)
+	}
+	if result != ":Cancel" {
+		t.Errorf("got %q; want %q", result, ":Cancel")
+	}
+	if closed != "Close:fail" {
+		t.Errorf("got %q; want %q", closed, "Close:fail")
+	}
+}
+
+func TestDeferNamed(t *testing.T) {
+	var result string
+	var err error
+	func() {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Defer(func() { result += ":first" })
+		e.DeferNamed("rollback", func() { result += ":rollback" }, Discard)
+		e.DeferNamed("flush", func() { result += ":flush" })
+		e.Defer(func() { result += ":last" })
+
+		if e.CancelDefer("unknown") || e.Promote("unknown") {
+			t.Error("found unknown deferred function")
+		}
+		if !e.Promote("flush") {
+			t.Error("flush not found")
+		}
+		if e.Promote("flush") {
+			t.Error("flush found after it was promoted")
+		}
+		if !e.CancelDefer("rollback") {
+			t.Error("rollback not found")
+		}
+		result += ":return"
+		if got := (*state)(&e).Pending(); len(got) != 2 {
+			t.Errorf("got pending %v; want 2 deferred functions", got)
+		}
+	}()
+	if want := ":flush:return:last:first"; result != want {
+		t.Errorf("got %q; want %q", result, want)
+	}
+}
+
+func TestPromoteError(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		catch func(err *error, h ...Handler) Catcher
+		want  string
+	}{
+		{"panic", Catch, ":first"},
+		{"lite", CatchLite, ":first:failed"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var result string
+			var err error
+			func() error {
+				e := tc.catch(&err)
+				defer e.Handle()
+				e.Defer(func() { result += ":first" })
+				e.DeferNamed("commit", func() error { return errors.New("fail") }, Wrap("commit"))
+				e.Promote("commit")
+				if e.Failed() {
+					result += ":failed"
+					return nil
+				}
+				result += ":unreachable"
+				return nil
+			}()
+			if err == nil || err.Error() != "commit: fail" {
+				t.Errorf("got %v; want commit: fail", err)
+			}
+			if result != tc.want {
+				t.Errorf("got %q; want %q", result, tc.want)
+			}
+		})
+	}
+}
@@ -86,6 +86,12 @@
 // ignored explicitly using the Discard error handler,
 // making it clear that this is what the programmer intended.
 //
+// A function that converts panics to errors with a PanicHandler may end up
+// without an error even though it did not complete. Passing NotNil to Defer
+// guarantees that CloseWithError is still called with a non-nil error:
+//
+//        e.Defer(w.CloseWithError, errc.NotNil(errAborted))
+//
 //
 // Error Handlers
 //
@@ -119,4 +125,13 @@
 //         return nil
 //     }
 //
+// Package errc provides the Wrap and Wrapf handlers for the common case of
+// adding a message. The resulting errors implement Unwrap, so the original
+// error can still be inspected with errors.Is and errors.As:
+//
+//     e.Must(err, errc.Wrap("error copying contents"))
+//
+// Errors created by package errc itself, such as the *PanicError recorded for
+// a panic, also preserve the chain of wrapped errors.
+//
 package errc
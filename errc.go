@@ -5,8 +5,10 @@
 package errc
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"runtime"
 )
 
 // Catch returns an error Catcher, which is used to funnel errors from panics
@@ -15,12 +17,48 @@ import (
 // Catcher as follows:
 //     e := errc.Catch(&err)
 //     defer e.Handle()
+//
+// Any Options passed to Catch configure the Catcher. The remaining Handlers are
+// used as default handlers for calls to Must and Defer that do not specify any
+// handlers. If there are none, the handlers of a Config passed to Catch or
+// those installed with SetDefaultHandlers are used instead.
 func Catch(err *error, h ...Handler) Catcher {
-	ec := Catcher{core{defaultHandlers: h, err: err}}
+	ec := Catcher{core{err: err}}
 	ec.deferred = ec.buf[:0]
+	ec.defaultHandlers = configure(&ec.core, h)
 	return ec
 }
 
+// CatchContext is like Catch, but associates a context derived from ctx with
+// the Catcher, as with WithContext.
+func CatchContext(ctx context.Context, err *error, h ...Handler) Catcher {
+	ec := Catcher{core{err: err}}
+	ec.deferred = ec.buf[:0]
+	ec.defaultHandlers = configure(&ec.core, h)
+	ec.WithContext(ctx)
+	return ec
+}
+
+// WithContext associates a context derived from ctx with e and returns it.
+// The derived context is canceled when the deferred functions of e are run.
+// Handlers can obtain the context through State.Context.
+//
+// Once a context is associated with e, Must reports the error of the context,
+// if any, when it is passed a nil error, so that a function stops at the first
+// check after its context is canceled.
+func (e *Catcher) WithContext(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	e.ctx = ctx
+	e.deferFunc(cancel, cancelFunc)
+	return ctx
+}
+
+// Context returns the context associated with e by CatchContext or
+// WithContext, or context.Background() if there is none.
+func (e *Catcher) Context() context.Context {
+	return (*state)(e).Context()
+}
+
 const bufSize = 3
 
 type core struct {
@@ -28,17 +66,125 @@ type core struct {
 	deferred        []deferData
 	buf             [bufSize]deferData
 	err             *error
+	ctx             context.Context
+	parent          *Catcher // Catcher of which this is a scope
+	x               *extra   // state of rarely used features; see ext
+	cur             handling
+	stats           Stats
+	depth           int    // number of active calls to Handle
+	gen             uint32 // incremented each time the Catcher is reset
 	inPanic         bool
+	done            bool // Handle has completed
+	pooled          bool // return to the pool after Handle
+	checkTypedNil   bool
+	collect         bool // join errors instead of keeping the first
+	joinDefers      bool // join errors of deferred functions
+	recordCallers   bool
+	lite            bool // do not panic on failure
+	failed          bool // Must detected an error
+	registered      bool // the scope is registered with its parent
+	safe            bool // created with CatchSafe
+}
+
+// extra holds the state of features that are rarely used or only used once an
+// error occurs. Keeping it out of core keeps Catchers small, which makes them
+// cheap to create and reset.
+type extra struct {
+	buckets         map[string][]error
+	priority        int // priority of the recorded error
+	report          *reporter
+	fields          []Field // annotations for all recorded errors
+	annotations     []Field // annotations of the operation
+	deadLetters     DeadLetterWriter
+	shutdownCtx     context.Context
+	callerSkip      int
+	typedNilHandler Handler
+	joined          error   // the last join of errs
+	errs            []error // errors joined in joined
+	observer        Observer
+	panicHandler    PanicHandler
+	t               TB              // test to fail, for Catchers created with CatchT
+	tStack          []runtime.Frame // stack of the first failed Must, if t is set
+	sentinel        error           // error reported by State.Err instead of nil; see NotNil
+	safe            *safeState
+	handlers        []Handler     // default handlers set by a Config
+	fatal           *FatalHandler // exits the process in finish, if set
+	recorded        error         // the error recorded by a lite Catcher
+}
+
+// ext returns the extra state of c, allocating it if needed.
+func (c *core) ext() *extra {
+	if c.x == nil {
+		c.x = &extra{}
+	}
+	return c.x
+}
+
+// noExtra is the extra state of Catchers that do not use any of its features.
+// It must not be modified.
+var noExtra extra
+
+// extOrZero returns the extra state of c for reading.
+func (c *core) extOrZero() *extra {
+	if c.x == nil {
+		return &noExtra
+	}
+	return c.x
+}
+
+// handling holds the state of the error currently passing through a handler
+// chain.
+type handling struct {
+	source   Source
+	pos      int     // position of the deferred function in the defer stack
+	caller   uintptr // location of the Must or Defer call
+	priority int
+	join     bool // join the error with the recorded error
+}
+
+// CatchLite is like Catch, but returns a Catcher for which Must does not cause
+// a return from the function if it detects an error. Instead, Must records the
+// error, runs all deferred functions, and returns normally. The caller must
+// check Failed to return from the function:
+//
+//     e.Must(err)
+//     if e.Failed() {
+//         return
+//     }
+//
+// This avoids the cost of a panic for each error in hot paths and keeps
+// profiles and debuggers free of the panics used internally by Must. Deferred
+// functions registered after a failure are run by Handle. As with Catch, the
+// recorded error is returned, even if the function returns a different value
+// after checking Failed.
+func CatchLite(err *error, h ...Handler) Catcher {
+	ec := Catch(err, h...)
+	ec.lite = true
+	return ec
 }
 
 // A Catcher coordinates error and defer handling.
 type Catcher struct{ core }
 
+// Failed reports whether Must detected an error that was not discarded by a
+// handler.
+func (e *Catcher) Failed() bool {
+	return e.failed
+}
+
 var errHandlerFirst = errors.New("errd: handler may not be first argument")
 
 // Must causes a return from a function if err is not nil, and after the error
-// is not nullified by any of the Handlers.
+// is not nullified by any of the Handlers. If e has a context and err is nil,
+// Must checks the error of the context instead.
 func (e *Catcher) Must(err error, h ...Handler) {
+	if e.safe && mustSafe(e, err, h) {
+		return
+	}
+	e.stats.Musts++
+	if err == nil && e.ctx != nil {
+		err = e.ctx.Err()
+	}
 	if err != nil {
 		processError(e, err, h)
 	}
@@ -47,14 +193,77 @@ func (e *Catcher) Must(err error, h ...Handler) {
 // State represents the error state passed to custom error handlers.
 type State interface {
 	// Panicking reports whether the error resulted from a panic. If true,
-	// the panic will be resume after error handling completes. An error handler
-	// cannot rewrite an error when panicing.
+	// the panic will be resume after error handling completes, unless a
+	// PanicHandler recovered from it. An error handler cannot rewrite an error
+	// when panicing.
 	Panicking() bool
 
 	// Err reports the first error that passed through an error handler chain.
 	// Note that this is always a different error (or nil) than the one passed
 	// to an error handler.
 	Err() error
+
+	// Pending reports the labels of the deferred functions that have not yet
+	// been run, in the order in which they will be run. The label of a
+	// deferred function is the one passed with a Label handler or the name of
+	// the function otherwise.
+	Pending() []string
+
+	// Context returns the context associated with the Catcher by CatchContext
+	// or WithContext, or context.Background() if there is none.
+	Context() context.Context
+
+	// Stack returns the stack trace of the point at which the current error
+	// was detected, omitting the frames of package errc. The stack trace of a
+	// panic is recorded in the *PanicError reported by Err.
+	Stack() []runtime.Frame
+
+	// Caller reports the location of the call to Must or Defer for which the
+	// current error is handled. Its result is only valid if the Catcher was
+	// created with the RecordCallers option and is the zero Frame otherwise.
+	Caller() runtime.Frame
+
+	// Source reports where the current error originated.
+	Source() Source
+
+	// Deferred identifies the deferred function that returned the current
+	// error if Source returns SourceDefer. It reports the position of the
+	// function in the order in which deferred functions were registered,
+	// starting at 0, and its label. The label of a deferred function is the one
+	// passed with a Label handler or the name of the function otherwise. It
+	// returns -1 and the empty string for errors of other sources.
+	Deferred() (index int, label string)
+
+	// Stats reports the error handling statistics of the Catcher so far,
+	// including those of the Scope, if any, in which the error is handled.
+	Stats() Stats
+}
+
+// A Source identifies where an error originated.
+type Source int
+
+const (
+	// SourceMust indicates an error detected by Must.
+	SourceMust Source = iota
+
+	// SourceDefer indicates an error returned by a deferred function.
+	SourceDefer
+
+	// SourcePanic indicates a panic caught by Handle.
+	SourcePanic
+)
+
+var sourceNames = [...]string{
+	SourceMust:  "must",
+	SourceDefer: "defer",
+	SourcePanic: "panic",
+}
+
+func (s Source) String() string {
+	if s < 0 || int(s) >= len(sourceNames) {
+		return fmt.Sprintf("Source(%d)", int(s))
+	}
+	return sourceNames[s]
 }
 
 type state struct{ core }
@@ -65,52 +274,173 @@ func (s *state) Err() error {
 	if s.err == nil {
 		return nil
 	}
+	if *s.err == nil && s.x != nil {
+		return s.x.sentinel
+	}
 	return *s.err
 }
 
+func (s *state) Pending() []string { return pending(s.deferred) }
+
+func (s *state) Stack() []runtime.Frame { return callers(s) }
+
+func (s *state) Caller() runtime.Frame { return frameAt(s.cur.caller) }
+
+func (s *state) Source() Source { return s.cur.source }
+
+func (s *state) Deferred() (index int, label string) {
+	if s.cur.source != SourceDefer {
+		return -1, ""
+	}
+	// The entry of the function is still held by the underlying array.
+	return numDeferred(s.deferred[:s.cur.pos]), deferLabel(s.deferred[:s.cur.pos+1])
+}
+
+func (s *state) Stats() Stats {
+	stats := s.stats
+	for p := s.parent; p != nil; p = p.parent {
+		stats.add(p.stats)
+	}
+	return stats
+}
+
+func (s *state) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
 var errOurPanic = errors.New("errd: our panic")
 
+// A PanicError is recorded in the error variable when a function panics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the point at which the panic occurred,
+	// omitting the frames of package errc.
+	Stack []runtime.Frame
+}
+
+func (e *PanicError) Error() string {
+	if err, ok := e.Value.(error); ok {
+		return err.Error()
+	}
+	return fmt.Sprintf("errd: paniced: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, or nil otherwise.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
 // Handle manages the error handling and defer processing. It must be called
 // after any call to Catch.
 func (e *Catcher) Handle() {
+	e.depth++
 	switch r := recover(); r {
 	case nil:
+		switch {
+		case e.collect:
+			restoreCollected(e)
+		case e.lite && e.failed:
+			restoreLite(e)
+		}
 		finishDefer(e)
 	case errOurPanic:
 		finishDefer(e)
 	default:
 		e.inPanic = true
-		err2, ok := r.(error)
-		if !ok {
-			err2 = fmt.Errorf("errd: paniced: %v", r)
+		err2 := &PanicError{Value: r, Stack: callers((*state)(e))}
+		x := e.extOrZero()
+		*e.err = WithFields(err2, x.fields...)
+		e.cur = handling{source: SourcePanic}
+		if o := observer(e); o != nil {
+			o.OnPanic((*state)(e), err2)
+		}
+		repanic := true
+		if x.panicHandler != nil {
+			var err error
+			err, repanic = x.panicHandler.HandlePanic((*state)(e), err2)
+			*e.err = WithFields(err, x.fields...)
+		}
+		if x.report != nil {
+			x.report.addAttempt(SourcePanic, err2, *e.err, *e.err == nil)
 		}
-		*e.err = err2
 		finishDefer(e)
-		// Check whether there are still defers left to do and then
-		// recursively defer.
-		panic(r)
+		finish(e)
+		if repanic {
+			// Check whether there are still defers left to do and then
+			// recursively defer.
+			panic(r)
+		}
+	}
+	finish(e)
+	if e.depth--; e.depth == 0 {
+		release(e)
+	}
+}
+
+// finish is called once all defers have completed. It is only effective the
+// first time it is called: when a deferred function panics, Handle is invoked
+// recursively and the outer invocations will call finish as well.
+func finish(e *Catcher) {
+	if e.done {
+		return
+	}
+	e.done = true
+	if e.x == nil {
+		return
+	}
+	if e.x.report != nil {
+		writeReport(e)
+	}
+	if err := (*state)(e).Err(); err != nil && e.x.deadLetters != nil {
+		writeDeadLetter(e, err)
+	}
+	if e.x.t != nil {
+		reportT(e)
+	}
+	if e.x.fatal != nil {
+		e.x.fatal.exit()
 	}
 }
 
-func doDefers(e *Catcher, barrier int) {
-	for len(e.deferred) > barrier {
+// doDefers runs the deferred functions above barrier and reports whether any of
+// them returned an error that was not discarded.
+func doDefers(e *Catcher, barrier int) (failed bool) {
+	for {
+		if e.safe {
+			failed = mergeSafe(e, true) || failed
+		}
+		if len(e.deferred) <= barrier {
+			break
+		}
 		i := len(e.deferred) - 1
 		d := e.deferred[i]
 		e.deferred = e.deferred[:i]
 		if d.f == nil {
 			continue
 		}
-		if err := d.f((*state)(e), d.x); err != nil {
-			processDeferError(e, err)
+		e.stats.DefersRun++
+		if e.x != nil && e.x.report != nil {
+			failed = runReported(e, d, deferLabel(e.deferred[:i+1])) || failed
+			continue
+		}
+		if err := runDefer(e, d); err != nil {
+			failed = processDeferError(e, d, err) || failed
 		}
 	}
+	return failed
 }
 
 // finishDefer processes remaining defers after we already have a panic.
 // We therefore ignore any panic caught here, knowing that we will panic on an
 // older panic after returning.
 func finishDefer(e *Catcher) {
-	if len(e.deferred) > 0 {
+	if len(e.deferred) > 0 || e.safe && !closeSafe(e) {
 		defer e.Handle()
 		doDefers(e, 0)
 	}
@@ -122,6 +452,7 @@ type errorHandler struct {
 }
 
 func (h errorHandler) handle(eh Handler) (done bool) {
+	h.e.stats.HandlerCalls++
 	newErr := eh.Handle((*state)(h.e), *h.err)
 	if newErr == nil {
 		return true
@@ -131,47 +462,178 @@ func (h errorHandler) handle(eh Handler) (done bool) {
 
 }
 
-func processDeferError(e *Catcher, err error) {
-	eh := errorHandler{e: e, err: &err}
+// processDeferError handles an error returned by the deferred function d and
+// reports whether it was recorded.
+func processDeferError(e *Catcher, d deferData, err error) (recorded bool) {
+	e.cur = handling{
+		source: SourceDefer,
+		pos:    len(e.deferred),
+		caller: d.pc,
+		join:   e.joinDefers,
+	}
+	orig := err
+	discarded := handleDeferError(e, &err)
+	e.stats.count(discarded)
+	if e.x != nil && e.x.report != nil {
+		e.x.report.addAttempt(SourceDefer, orig, err, discarded)
+	}
+	if discarded {
+		return false
+	}
+	if o := observer(e); o != nil {
+		o.OnDeferError((*state)(e), err)
+	}
+	record(e, err)
+	return true
+}
+
+// handleDeferError passes err through the handlers of the deferred function
+// that was just run, or the default handlers if it has none, and reports
+// whether the error was discarded.
+func handleDeferError(e *Catcher, err *error) (discarded bool) {
+	eh := errorHandler{e: e, err: err}
 	hadHandler := false
 	// Apply handlers added by Defer methods. A zero deferred value signals that
 	// we have custom defer handler for the subsequent fields.
 	for i := len(e.deferred); i > 0 && e.deferred[i-1].f == nil; i-- {
 		hadHandler = true
 		if eh.handle(e.deferred[i-1].x.(Handler)) {
-			return
+			return true
 		}
 	}
 	if !hadHandler {
 		for _, h := range e.defaultHandlers {
 			if eh.handle(h) {
-				return
+				return true
 			}
 		}
 	}
-	if *e.err == nil {
-		*e.err = err
-	}
+	return false
 }
 
 func processError(e *Catcher, err error, handlers []Handler) {
-	eh := errorHandler{e: e, err: &err}
+	if !checkError(e, err, handlers, 0) {
+		return
+	}
+	switch {
+	case e.collect:
+	case e.lite:
+		failLite(e)
+	default:
+		bail(e)
+	}
+}
+
+// checkError passes an error detected by Must through its handlers and records
+// the result, if any. It reports whether the error was recorded. The location
+// of the call to Must is pc or, if pc is 0, determined from the stack.
+func checkError(e *Catcher, err error, handlers []Handler, pc uintptr) (recorded bool) {
+	e.cur = handling{}
+	if e.checkTypedNil {
+		if err = checkTypedNil(e, err); err == nil {
+			return false
+		}
+	}
+	if e.recordCallers {
+		if pc == 0 {
+			pc = callerPC()
+		}
+		e.cur.caller = pc
+	}
+	orig := err
+	discarded := handleError(e, &err, handlers)
+	e.stats.count(discarded)
+	if e.x != nil && e.x.report != nil {
+		e.x.report.addAttempt(SourceMust, orig, err, discarded)
+	}
+	if discarded {
+		return false
+	}
+	if o := observer(e); o != nil {
+		o.OnError((*state)(e), err)
+	}
+	e.failed = true
+	if e.x != nil && e.x.t != nil && e.x.tStack == nil {
+		e.x.tStack = callers((*state)(e))
+	}
+	record(e, err)
+	return true
+}
+
+// handleError passes err through the given handlers, or the default handlers
+// if there are none, and reports whether the error was discarded.
+func handleError(e *Catcher, err *error, handlers []Handler) (discarded bool) {
+	eh := errorHandler{e: e, err: err}
+	if len(handlers) == 0 {
+		handlers = e.defaultHandlers
+	}
 	for _, h := range handlers {
 		if eh.handle(h) {
-			return
+			return true
 		}
 	}
-	if len(handlers) == 0 {
-		for _, h := range e.defaultHandlers {
-			if eh.handle(h) {
-				return
-			}
+	return false
+}
+
+// record stores err in the error variable if no error was recorded yet or if
+// err was assigned a higher priority than the recorded error. If the Catcher
+// collects errors or err is to be joined, err is joined with the recorded
+// error instead. Errors of scopes are recorded by the outermost Catcher.
+func record(e *Catcher, err error) {
+	c := &e.core
+	if e.parent != nil {
+		c = e.owner()
+		c.cur = e.cur
+	}
+	x := c.extOrZero()
+	switch {
+	case c.collect, c.cur.join && *c.err != nil:
+		*c.err = join(c, *c.err, WithFields(err, x.fields...))
+	case *c.err == nil || c.cur.priority > x.priority:
+		*c.err = WithFields(err, x.fields...)
+		if c.cur.priority != x.priority {
+			c.ext().priority = c.cur.priority
 		}
 	}
-	if *e.err == nil {
-		*e.err = err
+	if c.lite {
+		c.ext().recorded = *c.err
 	}
-	bail(e)
+}
+
+// join returns an error joining a and b with errors.Join. If a was created by
+// an earlier call to join, the errors it joins are joined with b directly.
+func join(c *core, a, b error) error {
+	x := c.ext()
+	if a != x.joined {
+		x.errs = x.errs[:0]
+		if a != nil {
+			x.errs = append(x.errs, a)
+		}
+	}
+	x.errs = append(x.errs, b)
+	x.joined = errors.Join(x.errs...)
+	return x.joined
+}
+
+// restoreCollected ensures that the errors collected by a collecting Catcher
+// are not lost when the function returns. An error returned by the function
+// is joined with the collected errors.
+func restoreCollected(e *Catcher) {
+	joined := e.extOrZero().joined
+	switch err := *e.err; {
+	case joined == nil || err == joined:
+	case err == nil:
+		*e.err = joined
+	default:
+		*e.err = join(&e.core, joined, err)
+	}
+}
+
+// restoreLite ensures that the error recorded by a lite Catcher is not lost
+// when the function returns a different error, such as nil, after checking
+// Failed. As with a regular Catcher, the recorded error takes precedence.
+func restoreLite(e *Catcher) {
+	*e.err = e.extOrZero().recorded
 }
 
 func bail(e *Catcher) {
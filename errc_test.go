@@ -2,9 +2,11 @@ package errc
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -174,6 +176,85 @@ func errdClosureDefer(w io.Writer, actions []int) errFunc {
 	}
 }
 
+func errdLiteDefer(w io.Writer, actions []int) errFunc {
+	closers := make([]idCloser, len(actions))
+	return func() (err error) {
+		e := CatchLite(&err)
+		defer e.Handle()
+		for i, a := range actions {
+			c, err := retDefer(w, closers, i, a)
+			if e.Must(err); e.Failed() {
+				return err
+			}
+			e.Defer(c.Close)
+		}
+		return err
+	}
+}
+
+func errdPooledDefer(w io.Writer, actions []int) errFunc {
+	closers := make([]idCloser, len(actions))
+	// Using an error variable that outlives the calls avoids allocating it.
+	var err error
+	return func() (result error) {
+		defer func() { result, err = err, nil }()
+		e := Pooled(&err)
+		defer e.Handle()
+		for i, a := range actions {
+			c, err := retDefer(w, closers, i, a)
+			e.Must(err)
+			e.DeferClose(c, identity)
+		}
+		return nil
+	}
+}
+
+// TestConformancePooled verifies that a pooled Catcher yields the same results
+// as a regular one.
+func TestConformancePooled(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(key(tc), func(t *testing.T) {
+			want := simulate(tc, properTraditionalDefer)
+			got := simulate(tc, errdPooledDefer)
+			if got != want {
+				t.Errorf("\n=== got:\n%s=== want:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestConformanceLite verifies that a lite Catcher yields the same results
+// as a regular one.
+func TestConformanceLite(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(key(tc), func(t *testing.T) {
+			want := simulate(tc, properTraditionalDefer)
+			got := simulate(tc, errdLiteDefer)
+			if got != want {
+				t.Errorf("\n=== got:\n%s=== want:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestLiteReturn(t *testing.T) {
+	errFail := errors.New("fail")
+	errOther := errors.New("other")
+	for _, ret := range []error{nil, errOther} {
+		err := func() (err error) {
+			e := CatchLite(&err)
+			defer e.Handle()
+			if e.Must(errFail); e.Failed() {
+				return ret
+			}
+			return nil
+		}()
+		if err != errFail {
+			t.Errorf("returning %v: got %v; want %v", ret, err, errFail)
+		}
+	}
+}
+
 func errdFuncDefer(w io.Writer, actions []int) errFunc {
 	closers := make([]idCloser, len(actions))
 	return func() (err error) {
@@ -288,6 +369,7 @@ var testFuncsDeferClose = []benchCase{
 	{"idiomatic traditional", idiomaticTraditionalDefer},
 	{"proper traditional", properTraditionalDefer},
 	{"errd/closer", errdClosureDefer},
+	{"errd/pooled", errdPooledDefer},
 }
 
 var testFuncsDeferCloseWithError = []benchCase{
@@ -298,6 +380,7 @@ var testFuncsDeferCloseWithError = []benchCase{
 var testFuncsNoDefer = []benchCase{
 	{"traditional", traditionalCheck},
 	{"errd", errdClosureCheck},
+	{"errd/lite", errdLiteCheck},
 }
 
 func traditionalCheck(w io.Writer, actions []int) errFunc {
@@ -323,6 +406,19 @@ func errdClosureCheck(w io.Writer, actions []int) errFunc {
 	}
 }
 
+func errdLiteCheck(w io.Writer, actions []int) errFunc {
+	return func() (err error) {
+		e := CatchLite(&err)
+		defer e.Handle()
+		for i, a := range actions {
+			if e.Must(retNoDefer(w, i, a)); e.Failed() {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 func retDefer(w io.Writer, closers []idCloser, id, action int) (io.Closer, error) {
 	// pre-allocate io.Closers. This is not realistice, but eliminates this
 	// allocation from the measurements.
@@ -338,7 +434,7 @@ func retDefer(w io.Writer, closers []idCloser, id, action int) (io.Closer, error
 	panic("errd: unreachable")
 }
 
-func retDeferWithErr(w io.Writer, closers []idCloser, id, action int) (closerWithError, error) {
+func retDeferWithErr(w io.Writer, closers []idCloser, id, action int) (CloserWithError, error) {
 	// pre-allocate io.Closers. This is not realistice, but eliminates this
 	// allocation from the measurements.
 	closers[id] = idCloser{id, action, w}
@@ -428,6 +524,35 @@ func BenchmarkDeferClose(b *testing.B) {
 func BenchmarkDeferCloseWithError(b *testing.B) {
 	runBenchCases(b, testFuncsDeferCloseWithError)
 }
+
+var failBenchCases = [][]int{
+	{retError},
+	{success, retError},
+	{success, success, success, retError},
+}
+
+// BenchmarkFail compares the cost of detecting an error using the default
+// panic-based Catcher and a lite one.
+func BenchmarkFail(b *testing.B) {
+	bf := []benchCase{
+		{"traditional", traditionalCheck},
+		{"errd", errdClosureCheck},
+		{"errd/lite", errdLiteCheck},
+		{"errd/closer", errdClosureDefer},
+		{"errd/lite/closer", errdLiteDefer},
+	}
+	for _, actions := range failBenchCases {
+		for _, bf := range bf {
+			b.Run(key(actions)+"/"+bf.name, func(b *testing.B) {
+				f := bf.f(nil, actions)
+				for i := 0; i < b.N; i++ {
+					f()
+				}
+			})
+		}
+	}
+}
+
 func TestPanic(t *testing.T) {
 	errFoo := errors.New("foo")
 	testCases := []struct {
@@ -500,3 +625,84 @@ func TestPanic(t *testing.T) {
 		})
 	}
 }
+
+func TestContext(t *testing.T) {
+	var got context.Context
+	var result string
+	err := func() (err error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		e := CatchContext(ctx, &err)
+		defer e.Handle()
+		got = e.Context()
+		e.Must(nil)
+		if got.Err() != nil {
+			t.Errorf("context canceled before return")
+		}
+		cancel()
+		e.Must(nil)
+		result = "unreachable"
+		return nil
+	}()
+	if err != context.Canceled {
+		t.Errorf("got %v; want %v", err, context.Canceled)
+	}
+	if result != "" {
+		t.Errorf("Must did not return after cancelation")
+	}
+
+	func() {
+		var err error
+		e := Catch(&err)
+		defer e.Handle()
+		got = e.WithContext(context.Background())
+		if e.Context() != got {
+			t.Errorf("Context does not return the context of WithContext")
+		}
+	}()
+	if got.Err() != context.Canceled {
+		t.Errorf("got %v; want context to be canceled after return", got.Err())
+	}
+}
+
+func TestSource(t *testing.T) {
+	type source struct {
+		src   Source
+		index int
+		label string
+	}
+	var got []source
+	h := HandlerFunc(func(s State, err error) error {
+		i, l := s.Deferred()
+		got = append(got, source{s.Source(), i, l})
+		return err
+	})
+	Run(func(e *Catcher) error {
+		e.Defer(func() error { return err1 }, Label("first"), h)
+		e.Defer(func() {})
+		e.Defer(func() error { return err2 }, Label("third"), h)
+		e.Must(err0, h)
+		return nil
+	})
+	want := []source{
+		{SourceMust, -1, ""},
+		{SourceDefer, 2, "third"},
+		{SourceDefer, 0, "first"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	var src Source
+	func() {
+		defer func() { recover() }()
+		Run(func(e *Catcher) error {
+			panic("boom")
+		}, HandlePanics(PanicHandlerFunc(func(s State, p *PanicError) (error, bool) {
+			src = s.Source()
+			return p, true
+		})))
+	}()
+	if src != SourcePanic {
+		t.Errorf("got %v; want %v", src, SourcePanic)
+	}
+}
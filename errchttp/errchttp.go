@@ -0,0 +1,188 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package errchttp adapts functions using package errc to HTTP handlers.
+//
+// A handler creates a Catcher for each request, converts panics to errors, and
+// maps the resulting error to an HTTP status code:
+//
+//    http.Handle("/item", errchttp.Handler(func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+//        item, err := lookup(r.Context(), r.FormValue("id"))
+//        e.Must(err)
+//        ...
+//        return nil
+//    }))
+//
+package errchttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/mpvl/errc"
+)
+
+// Errors that are mapped to the corresponding status codes by DefaultMapper.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+)
+
+// A HandlerFunc handles an HTTP request using the Catcher e. An error returned
+// by the function, detected by e, or resulting from a panic is mapped to the
+// response status code.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error
+
+// DefaultMapper maps errors to HTTP status codes as follows:
+//
+//    errc.Coder with a code in 400-599  the code
+//    ErrBadRequest                      400 Bad Request
+//    ErrUnauthorized                    401 Unauthorized
+//    ErrForbidden, fs.ErrPermission     403 Forbidden
+//    ErrNotFound, fs.ErrNotExist        404 Not Found
+//    context.DeadlineExceeded           504 Gateway Timeout
+//    temporary errors                   503 Service Unavailable
+//
+// An error is temporary if errc.IsTemporary reports true for it, for instance
+// because it was annotated with errc.Temporary. All other errors, including
+// panics, map to 500 Internal Server Error.
+func DefaultMapper(err error) int {
+	if code, ok := errc.Code(err); ok && code >= 400 && code < 600 {
+		return code
+	}
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden), errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	case errors.Is(err, ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errc.IsTemporary(err):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// A Config configures the handlers created with its Handler method.
+type Config struct {
+	// Mapper maps errors to status codes. If nil, DefaultMapper is used.
+	Mapper func(err error) int
+
+	// Logger logs errors that map to a 5xx status code, including panics.
+	// If nil, slog.Default() is used.
+	Logger *slog.Logger
+
+	// Handlers are passed to the Catcher of each request.
+	Handlers []errc.Handler
+}
+
+// Handler returns an http.Handler for f using the default Config.
+func Handler(f HandlerFunc) http.Handler {
+	return (&Config{}).Handler(f)
+}
+
+// Handler returns an http.Handler that calls f with a Catcher for each
+// request. The request passed to f carries the context of the Catcher, which
+// is canceled when f returns.
+//
+// If an error occurs, the handler responds with the status code returned by
+// the mapper and the corresponding status text. No response is written if f
+// already wrote the response header. A panic with http.ErrAbortHandler is
+// resumed, to abort the response as expected by net/http.
+//
+// The ResponseWriter passed to f wraps the one of the server. It implements
+// http.Flusher and http.Hijacker, for instance for streaming responses and
+// WebSockets; other optional interfaces of the underlying ResponseWriter are
+// available through http.ResponseController.
+func (c *Config) Handler(f HandlerFunc) http.Handler {
+	handlers := append(c.Handlers[:len(c.Handlers):len(c.Handlers)], errc.HandlePanics(panicHandler))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		err := errc.RunWithContext(r.Context(), func(ctx context.Context, e *errc.Catcher) error {
+			return f(rw, r.WithContext(ctx), e)
+		}, handlers...)
+		if err != nil {
+			c.writeError(rw, r, err)
+		}
+	})
+}
+
+var panicHandler = errc.PanicHandlerFunc(func(s errc.State, p *errc.PanicError) (error, bool) {
+	return p, p.Value == http.ErrAbortHandler
+})
+
+func (c *Config) writeError(w *responseWriter, r *http.Request, err error) {
+	mapper := c.Mapper
+	if mapper == nil {
+		mapper = DefaultMapper
+	}
+	code := mapper(err)
+	if code >= 500 {
+		l := c.Logger
+		if l == nil {
+			l = slog.Default()
+		}
+		var p *errc.PanicError
+		l.ErrorContext(r.Context(), "request failed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", code,
+			"error", err,
+			"panic", errors.As(err, &p))
+	}
+	if !w.wroteHeader {
+		http.Error(w, http.StatusText(code), code)
+	}
+}
+
+// responseWriter records whether the response header was written.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher. It does nothing if the underlying
+// ResponseWriter does not support flushing.
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker. It returns an error wrapping
+// http.ErrNotSupported if the underlying ResponseWriter does not support
+// hijacking.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying ResponseWriter for use with
+// http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
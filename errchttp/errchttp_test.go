@@ -0,0 +1,164 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errchttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mpvl/errc"
+)
+
+func TestHandler(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		f      HandlerFunc
+		code   int
+		body   string
+		logged bool
+	}{{
+		desc: "ok",
+		f: func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+			fmt.Fprint(w, "hello")
+			return nil
+		},
+		code: 200,
+		body: "hello",
+	}, {
+		desc: "must",
+		f: func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+			e.Must(ErrNotFound, errc.Wrap("item 3"))
+			return nil
+		},
+		code: 404,
+		body: "Not Found\n",
+	}, {
+		desc: "returned",
+		f: func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+			_, err := os.Open("/does/not/exist")
+			return err
+		},
+		code: 404,
+		body: "Not Found\n",
+	}, {
+		desc: "code",
+		f: func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+			e.Must(ErrNotFound, errc.WithCode(http.StatusConflict))
+			return nil
+		},
+		code: 409,
+		body: "Conflict\n",
+	}, {
+		desc: "temporary",
+		f: func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+			e.Must(errors.New("overloaded"), errc.Temporary)
+			return nil
+		},
+		code:   503,
+		body:   "Service Unavailable\n",
+		logged: true,
+	}, {
+		desc: "internal",
+		f: func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+			e.Defer(func() error { return errors.New("close failed") })
+			return nil
+		},
+		code:   500,
+		body:   "Internal Server Error\n",
+		logged: true,
+	}, {
+		desc: "panic",
+		f: func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+			panic("boom")
+		},
+		code:   500,
+		body:   "Internal Server Error\n",
+		logged: true,
+	}, {
+		desc: "header written",
+		f: func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+			w.WriteHeader(http.StatusAccepted)
+			return ErrBadRequest
+		},
+		code: 202,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			c := &Config{Logger: slog.New(slog.NewTextHandler(buf, nil))}
+			w := httptest.NewRecorder()
+			c.Handler(tc.f).ServeHTTP(w, httptest.NewRequest("GET", "/item", nil))
+			if w.Code != tc.code {
+				t.Errorf("code: got %d; want %d", w.Code, tc.code)
+			}
+			if got := w.Body.String(); got != tc.body {
+				t.Errorf("body: got %q; want %q", got, tc.body)
+			}
+			if logged := strings.Contains(buf.String(), "request failed"); logged != tc.logged {
+				t.Errorf("logged: got %v; want %v (log %q)", logged, tc.logged, buf)
+			}
+		})
+	}
+}
+
+func TestMapper(t *testing.T) {
+	c := &Config{Mapper: func(err error) int { return http.StatusTeapot }}
+	w := httptest.NewRecorder()
+	c.Handler(func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+		return errors.New("tea")
+	}).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("got %d; want %d", w.Code, http.StatusTeapot)
+	}
+}
+
+func TestAbortHandler(t *testing.T) {
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("got panic %v; want %v", r, http.ErrAbortHandler)
+		}
+	}()
+	Handler(func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+		panic(http.ErrAbortHandler)
+	}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestFlusher(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("ResponseWriter does not implement http.Flusher")
+		}
+		f.Flush()
+		return errors.New("after flush")
+	}).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !rec.Flushed {
+		t.Error("response was not flushed")
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("got %d; want %d as the header was already written", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHijacker(t *testing.T) {
+	Handler(func(w http.ResponseWriter, r *http.Request, e *errc.Catcher) error {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			t.Fatal("ResponseWriter does not implement http.Hijacker")
+		}
+		if _, _, err := h.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("got %v; want %v", err, http.ErrNotSupported)
+		}
+		return nil
+	}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package errclog provides an errc.Handler that logs errors using log/slog.
+//
+// A Handler can be used as a default handler of a Catcher or per call:
+//
+//    e := errc.Catch(&err, &errclog.Handler{Message: "upload failed"})
+//    defer e.Handle()
+//
+//    e.Must(cache.Put(key, v), errclog.Discard(nil))
+//
+package errclog
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/mpvl/errc"
+)
+
+// A Handler is an errc.Handler that logs each error it handles. Each log record
+// has the attributes
+//
+//    error      the error
+//    panicking  whether the error resulted from a panic
+//    caller     the location of the Must or Defer call, if recorded
+//
+// followed by the fields of the error; see errc.Fields. Callers are only
+// recorded for Catchers created with the errc.RecordCallers option.
+type Handler struct {
+	// Logger is the logger to log to. If nil, slog.Default() is used.
+	Logger *slog.Logger
+
+	// Level is the level of the records. If nil, slog.LevelError is used.
+	Level slog.Leveler
+
+	// Message is the message of the records. If empty, "error" is used.
+	Message string
+
+	// Discard causes the handler to discard errors after logging them.
+	// Otherwise errors are passed on unmodified.
+	Discard bool
+}
+
+// Log returns a Handler that logs errors to l at level Error and passes them
+// on. If l is nil, slog.Default() is used.
+func Log(l *slog.Logger) *Handler {
+	return &Handler{Logger: l}
+}
+
+// Discard returns a Handler that logs errors to l at level Error and discards
+// them. If l is nil, slog.Default() is used.
+func Discard(l *slog.Logger) *Handler {
+	return &Handler{Logger: l, Discard: true}
+}
+
+// Handle implements errc.Handler.
+func (h *Handler) Handle(s errc.State, err error) error {
+	l := h.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+	level := slog.LevelError
+	if h.Level != nil {
+		level = h.Level.Level()
+	}
+	ctx := s.Context()
+	if l.Enabled(ctx, level) {
+		msg := h.Message
+		if msg == "" {
+			msg = "error"
+		}
+		attrs := []slog.Attr{
+			slog.Any("error", err),
+			slog.Bool("panicking", s.Panicking()),
+		}
+		if f := s.Caller(); f.PC != 0 {
+			attrs = append(attrs, slog.String("caller", fmt.Sprintf("%s:%d", f.File, f.Line)))
+		}
+		for _, f := range errc.Fields(err) {
+			attrs = append(attrs, slog.Any(f.Key, f.Value))
+		}
+		l.LogAttrs(ctx, level, msg, attrs...)
+	}
+	if h.Discard {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mpvl/errc"
+)
+
+func TestHandler(t *testing.T) {
+	errFoo := errors.New("foo")
+	testCases := []struct {
+		desc    string
+		h       *Handler
+		err     error
+		level   string
+		message string
+	}{{
+		desc:    "propagate",
+		h:       &Handler{},
+		err:     errFoo,
+		level:   "ERROR",
+		message: "error",
+	}, {
+		desc:    "discard",
+		h:       &Handler{Discard: true, Level: slog.LevelWarn, Message: "cleanup failed"},
+		err:     nil,
+		level:   "WARN",
+		message: "cleanup failed",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tc.h.Logger = slog.New(slog.NewJSONHandler(buf, nil))
+			err := errc.Run(func(e *errc.Catcher) error {
+				e.Must(errFoo, errc.Capture("a"), tc.h)
+				return nil
+			}, errc.RecordCallers)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("got %v; want %v", err, tc.err)
+			}
+			var rec map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec["level"] != tc.level {
+				t.Errorf("level: got %v; want %v", rec["level"], tc.level)
+			}
+			if rec["msg"] != tc.message {
+				t.Errorf("msg: got %v; want %v", rec["msg"], tc.message)
+			}
+			if rec["error"] != "foo" {
+				t.Errorf("error: got %v; want foo", rec["error"])
+			}
+			if rec["panicking"] != false {
+				t.Errorf("panicking: got %v; want false", rec["panicking"])
+			}
+			if c, _ := rec["caller"].(string); !strings.Contains(c, "errclog_test.go:") {
+				t.Errorf("caller: got %q; want location in errclog_test.go", c)
+			}
+			if _, ok := rec["args"]; !ok {
+				t.Errorf("missing field args of the error")
+			}
+		})
+	}
+}
+
+func TestHandlerLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelError}))
+	errc.Run(func(e *errc.Catcher) error {
+		e.Must(errors.New("foo"), &Handler{Logger: l, Level: slog.LevelInfo}, errc.Discard)
+		return nil
+	})
+	if buf.Len() != 0 {
+		t.Errorf("got %q; want no output below the logger's level", buf)
+	}
+}
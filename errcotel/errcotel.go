@@ -0,0 +1,54 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package errcotel provides an errc.Observer that records errors on
+// OpenTelemetry spans.
+//
+// The Observer records errors on the span of the context of a Catcher, as
+// passed to errc.CatchContext. To enable it for all Catchers, use
+//
+//    errc.SetObserver(errcotel.Observer{})
+//
+package errcotel
+
+import (
+	"github.com/mpvl/errc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Observer is an errc.Observer that records each error on the span of the
+// context of the Catcher with RecordError and sets the status of the span to
+// codes.Error. Errors of Catchers without a recording span are ignored.
+//
+// Recorded errors carry the attribute errc.source, which is one of "must",
+// "defer", or "panic".
+type Observer struct{}
+
+var _ errc.Observer = Observer{}
+
+// OnError implements errc.Observer.
+func (Observer) OnError(s errc.State, err error) {
+	record(s, err, "must")
+}
+
+// OnDeferError implements errc.Observer.
+func (Observer) OnDeferError(s errc.State, err error) {
+	record(s, err, "defer")
+}
+
+// OnPanic implements errc.Observer.
+func (Observer) OnPanic(s errc.State, err *errc.PanicError) {
+	record(s, err, "panic")
+}
+
+func record(s errc.State, err error, source string) {
+	span := trace.SpanFromContext(s.Context())
+	if !span.IsRecording() {
+		return
+	}
+	span.RecordError(err, trace.WithAttributes(attribute.String("errc.source", source)))
+	span.SetStatus(codes.Error, err.Error())
+}
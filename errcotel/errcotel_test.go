@@ -0,0 +1,64 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errcotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mpvl/errc"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// span records the errors and status set by the Observer.
+type span struct {
+	noop.Span
+	errs   []error
+	status codes.Code
+	desc   string
+}
+
+func (s *span) IsRecording() bool { return true }
+
+func (s *span) RecordError(err error, opts ...trace.EventOption) {
+	s.errs = append(s.errs, err)
+}
+
+func (s *span) SetStatus(code codes.Code, desc string) {
+	s.status, s.desc = code, desc
+}
+
+func TestObserver(t *testing.T) {
+	sp := &span{}
+	ctx := trace.ContextWithSpan(context.Background(), sp)
+	err := errc.RunWithContext(ctx, func(ctx context.Context, e *errc.Catcher) error {
+		e.Defer(func() error { return errors.New("close") })
+		e.Must(errors.New("ignored"), errc.Discard)
+		e.Must(errors.New("fail"))
+		return nil
+	}, errc.Observe(Observer{}))
+	if err == nil || err.Error() != "fail" {
+		t.Errorf("got %v; want fail", err)
+	}
+	if len(sp.errs) != 2 || sp.errs[0].Error() != "fail" || sp.errs[1].Error() != "close" {
+		t.Errorf("got %v; want [fail close]", sp.errs)
+	}
+	if sp.status != codes.Error || sp.desc != "close" {
+		t.Errorf("got status %v %q; want Error %q", sp.status, sp.desc, "close")
+	}
+}
+
+func TestObserverNoSpan(t *testing.T) {
+	err := errc.Run(func(e *errc.Catcher) error {
+		e.Must(errors.New("fail"))
+		return nil
+	}, errc.Observe(Observer{}))
+	if err == nil {
+		t.Error("got nil; want error")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package errctest provides utilities for testing errc.Handlers.
+//
+// Handlers can be tested in isolation by passing them a synthetic State:
+//
+//    s := errctest.DeferState(0, "upload")
+//    err := errctest.Apply(s, io.ErrUnexpectedEOF, myHandler)
+//
+// Note that the handlers of package errc that update the state of a Catcher,
+// such as errc.Priority, errc.Join, and errc.Bucket, have no effect when
+// passed a synthetic State.
+package errctest
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/mpvl/errc"
+)
+
+// A State is an errc.State with fixed values. A zero State describes an error
+// detected by Must while not panicking.
+type State struct {
+	InPanic     bool            // reported by Panicking
+	Recorded    error           // reported by Err
+	Labels      []string        // reported by Pending
+	Ctx         context.Context // reported by Context; context.Background() if nil
+	Frames      []runtime.Frame // reported by Stack
+	CallerFrame runtime.Frame   // reported by Caller
+	From        errc.Source     // reported by Source
+	Index       int             // reported by Deferred for SourceDefer
+	Label       string          // reported by Deferred for SourceDefer
+	Counts      errc.Stats      // reported by Stats
+}
+
+var _ errc.State = (*State)(nil)
+
+// MustState returns a State for an error detected by Must.
+func MustState() *State {
+	return &State{From: errc.SourceMust}
+}
+
+// DeferState returns a State for an error returned by the deferred function
+// with the given index and label.
+func DeferState(index int, label string) *State {
+	return &State{From: errc.SourceDefer, Index: index, Label: label}
+}
+
+// PanicState returns a State for an error returned by a deferred function
+// while the function panicked with value v, as is the case for the handlers
+// of deferred functions run during a panic.
+func PanicState(index int, label string, v interface{}) *State {
+	return &State{
+		InPanic:  true,
+		Recorded: &errc.PanicError{Value: v},
+		From:     errc.SourceDefer,
+		Index:    index,
+		Label:    label,
+	}
+}
+
+// Panicking implements errc.State.
+func (s *State) Panicking() bool { return s.InPanic }
+
+// Err implements errc.State.
+func (s *State) Err() error { return s.Recorded }
+
+// Pending implements errc.State.
+func (s *State) Pending() []string { return s.Labels }
+
+// Context implements errc.State.
+func (s *State) Context() context.Context {
+	if s.Ctx == nil {
+		return context.Background()
+	}
+	return s.Ctx
+}
+
+// Stack implements errc.State.
+func (s *State) Stack() []runtime.Frame { return s.Frames }
+
+// Caller implements errc.State.
+func (s *State) Caller() runtime.Frame { return s.CallerFrame }
+
+// Source implements errc.State.
+func (s *State) Source() errc.Source { return s.From }
+
+// Deferred implements errc.State.
+func (s *State) Deferred() (index int, label string) {
+	if s.From != errc.SourceDefer {
+		return -1, ""
+	}
+	return s.Index, s.Label
+}
+
+// Stats implements errc.State.
+func (s *State) Stats() errc.Stats { return s.Counts }
+
+// Apply passes err through the handlers h with State s, as Must and Defer do.
+// It stops at the first handler that discards the error and returns nil in
+// that case.
+func Apply(s errc.State, err error, h ...errc.Handler) error {
+	for _, h := range h {
+		if err = h.Handle(s, err); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errctest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mpvl/errc"
+)
+
+// cleanup is a sample handler that decorates errors of deferred functions.
+var cleanup = errc.HandlerFunc(func(s errc.State, err error) error {
+	if s.Source() != errc.SourceDefer {
+		return err
+	}
+	_, label := s.Deferred()
+	if s.Panicking() {
+		return fmt.Errorf("cleanup of %s during panic: %w", label, err)
+	}
+	return fmt.Errorf("cleanup of %s: %w", label, err)
+})
+
+func TestApply(t *testing.T) {
+	errFoo := errors.New("foo")
+	testCases := []struct {
+		s    errc.State
+		h    []errc.Handler
+		want string
+	}{
+		{MustState(), []errc.Handler{cleanup}, "foo"},
+		{DeferState(1, "upload"), []errc.Handler{cleanup}, "cleanup of upload: foo"},
+		{PanicState(0, "tx", "boom"), []errc.Handler{cleanup}, "cleanup of tx during panic: foo"},
+		{&State{}, []errc.Handler{errc.Wrap("a"), errc.Wrap("b")}, "b: a: foo"},
+		{&State{}, []errc.Handler{errc.Discard, errc.Wrap("b")}, ""},
+	}
+	for _, tc := range testCases {
+		err := Apply(tc.s, errFoo, tc.h...)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tc.want {
+			t.Errorf("got %q; want %q", got, tc.want)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	s := &State{}
+	if s.Context() == nil {
+		t.Error("Context returned nil")
+	}
+	if i, l := s.Deferred(); i != -1 || l != "" {
+		t.Errorf("Deferred: got %d, %q; want -1, \"\"", i, l)
+	}
+	if _, ok := PanicState(0, "", "boom").Err().(*errc.PanicError); !ok {
+		t.Error("PanicState: Err does not report a *PanicError")
+	}
+}
@@ -149,3 +149,33 @@ func ExampleCatcher_Defer_cancelHelper() {
 		do(ctx)
 	})
 }
+
+type tempFile struct {
+	h    *errc.Helper
+	name string
+}
+
+// newTempFile creates a temporary file that is removed when the function
+// that owns e returns.
+func newTempFile(e *errc.Catcher) *tempFile {
+	f, err := ioutil.TempFile("", "errc")
+	e.Must(err)
+	t := &tempFile{h: e.Helper(), name: f.Name()}
+	t.h.Defer(func() error { return os.Remove(t.name) })
+	t.h.Defer(f.Close)
+	return t
+}
+
+// ExampleCatcher_Helper shows how a helper type may register defers in the
+// scope of its caller without holding on to the caller's Catcher.
+func ExampleCatcher_Helper() {
+	func() (err error) {
+		e := errc.Catch(&err)
+		defer e.Handle()
+
+		t := newTempFile(&e)
+		t.h.Must(ioutil.WriteFile(t.name, []byte("Hello World!"), 0644))
+		t.h.Detach()
+		return nil
+	}()
+}
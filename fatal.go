@@ -0,0 +1,87 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Fatal is a Handler that causes the process to exit with status 1 once all
+// deferred functions have run. It is a FatalHandler without a Logger.
+var Fatal Handler = &FatalHandler{}
+
+// FatalTo returns a Handler that logs errors, including the stack trace, to l
+// and causes the process to exit with status 1 once all deferred functions
+// have run.
+func FatalTo(l *slog.Logger) *FatalHandler {
+	return &FatalHandler{Logger: l}
+}
+
+// A FatalHandler is a Handler that terminates the process. It marks the Catcher
+// as failed fatally and passes the error on unmodified, so that Must causes a
+// return from the function as usual. Once Handle has run the remaining deferred
+// functions, for instance to flush logs and close files, the process exits.
+//
+// Tests can replace Exit to verify that code using a FatalHandler terminates:
+//
+//     var code int
+//     fatal := &errc.FatalHandler{Exit: func(c int) { code = c }}
+//
+// If Exit returns, the function returns normally with the recorded error. The
+// first FatalHandler that handles an error of a Catcher determines how the
+// process exits. Handlers following a FatalHandler are still run, but cannot
+// prevent the exit.
+type FatalHandler struct {
+	// Logger, if not nil, is used to log the error and the stack at which it
+	// was detected. Errors are logged before any deferred functions are run.
+	Logger *slog.Logger
+
+	// Code is the exit code. If 0, 1 is used.
+	Code int
+
+	// Exit terminates the process. If nil, os.Exit is used.
+	Exit func(code int)
+}
+
+// Handle implements Handler.
+func (f *FatalHandler) Handle(s State, err error) error {
+	if f.Logger != nil {
+		f.Logger.Error("errc: fatal error", "error", err, "stack", formatStack(s))
+	}
+	st, ok := s.(*state)
+	if !ok {
+		// Not passed by a Catcher, so there is no Handle to wait for.
+		f.exit()
+		return err
+	}
+	if x := st.owner().ext(); x.fatal == nil {
+		x.fatal = f
+	}
+	return err
+}
+
+func (f *FatalHandler) exit() {
+	code := f.Code
+	if code == 0 {
+		code = 1
+	}
+	if f.Exit != nil {
+		f.Exit(code)
+		return
+	}
+	os.Exit(code)
+}
+
+// formatStack returns the stack of s, one frame per element.
+func formatStack(s State) []string {
+	frames := s.Stack()
+	stack := make([]string, len(frames))
+	for i, f := range frames {
+		stack[i] = fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line)
+	}
+	return stack
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFatal(t *testing.T) {
+	errFail := errors.New("fail")
+	var events []string
+	exit := func(code int) { events = append(events, "exit") }
+	testCases := []struct {
+		desc string
+		h    *FatalHandler
+		f    func(e *Catcher)
+		code int
+	}{{
+		desc: "must",
+		h:    &FatalHandler{},
+		f:    func(e *Catcher) { e.Must(errFail) },
+		code: 1,
+	}, {
+		desc: "code",
+		h:    &FatalHandler{Code: 3},
+		f:    func(e *Catcher) { e.Must(errFail) },
+		code: 3,
+	}, {
+		desc: "defer",
+		h:    &FatalHandler{},
+		f: func(e *Catcher) {
+			e.Defer(func() error { return errFail })
+		},
+		code: 1,
+	}, {
+		desc: "scope",
+		h:    &FatalHandler{},
+		f: func(e *Catcher) {
+			s := e.Scope()
+			s.Defer(func() error { return errFail })
+			s.Flush()
+		},
+		code: 1,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			events = nil
+			code := 0
+			tc.h.Exit = func(c int) {
+				code = c
+				exit(c)
+			}
+			err := Run(func(e *Catcher) error {
+				e.Defer(func() { events = append(events, "deferred") })
+				tc.f(e)
+				events = append(events, "returned")
+				return nil
+			}, tc.h)
+			if err != errFail {
+				t.Errorf("got %v; want %v", err, errFail)
+			}
+			if code != tc.code {
+				t.Errorf("exit code: got %d; want %d", code, tc.code)
+			}
+			if n := len(events); n < 2 || !reflect.DeepEqual(events[n-2:], []string{"deferred", "exit"}) {
+				t.Errorf("got %q; want deferred functions to run before exit", events)
+			}
+		})
+	}
+}
+
+func TestFatalDiscarded(t *testing.T) {
+	exited := false
+	err := Run(func(e *Catcher) error {
+		e.Must(errors.New("discarded"), Discard)
+		return nil
+	}, &FatalHandler{Exit: func(int) { exited = true }})
+	if err != nil || exited {
+		t.Errorf("got %v, exited %v; want no error and no exit", err, exited)
+	}
+}
+
+func TestFatalTo(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := FatalTo(slog.New(slog.NewTextHandler(buf, nil)))
+	h.Exit = func(int) {}
+	Run(func(e *Catcher) error {
+		e.Must(errors.New("fail"), h)
+		return nil
+	})
+	log := buf.String()
+	for _, want := range []string{"errc: fatal error", "error=fail", "TestFatalTo"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log %q does not contain %q", log, want)
+		}
+	}
+}
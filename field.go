@@ -0,0 +1,84 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+// A Field is a key-value pair annotating an error.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// WithFields returns err annotated with the given fields. The returned error
+// has the same message as err and wraps it.
+func WithFields(err error, fields ...Field) error {
+	if err == nil || len(fields) == 0 {
+		return err
+	}
+	return &fieldError{err, fields}
+}
+
+type fieldError struct {
+	err    error
+	fields []Field
+}
+
+func (e *fieldError) Error() string { return e.err.Error() }
+func (e *fieldError) Unwrap() error { return e.err }
+
+// Capture returns a Handler that annotates errors with the given values,
+// typically the arguments of the failed call, as the field "args":
+//
+//     e.Must(os.Rename(src, dst), errc.Capture(src, dst))
+//
+// This preserves debugging context without formatting a message on the happy
+// path. Use CaptureFunc to defer the computation of values until an error
+// occurs.
+func Capture(vals ...interface{}) Handler {
+	return capture(vals)
+}
+
+type capture []interface{}
+
+func (c capture) Handle(s State, err error) error {
+	return WithFields(err, Field{"args", []interface{}(c)})
+}
+
+// CaptureFunc returns a Handler that annotates errors with the fields returned
+// by f. The function f is only called if an error occurs.
+func CaptureFunc(f func() []Field) Handler {
+	return HandlerFunc(func(s State, err error) error {
+		return WithFields(err, f()...)
+	})
+}
+
+// Fields returns the fields annotating err and the errors it wraps, starting
+// with the outermost error.
+func Fields(err error) []Field {
+	var fields []Field
+	walk(err, func(err error) {
+		if f, ok := err.(*fieldError); ok {
+			fields = append(fields, f.fields...)
+		}
+	})
+	return fields
+}
+
+// walk calls f for err and all errors it wraps, in pre-order.
+func walk(err error, f func(err error)) {
+	for err != nil {
+		f(err)
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range x.Unwrap() {
+				walk(err, f)
+			}
+			return
+		default:
+			return
+		}
+	}
+}
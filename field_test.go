@@ -0,0 +1,76 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	errFoo := errors.New("foo")
+	a := Field{"a", 1}
+	b := Field{"b", 2}
+	c := Field{"c", 3}
+	testCases := []struct {
+		err  error
+		want []Field
+	}{{
+		err: nil,
+	}, {
+		err: errFoo,
+	}, {
+		err:  WithFields(errFoo, a),
+		want: []Field{a},
+	}, {
+		err:  WithFields(WithFields(errFoo, b, c), a),
+		want: []Field{a, b, c},
+	}, {
+		err:  fmt.Errorf("wrap: %w", WithFields(errFoo, a)),
+		want: []Field{a},
+	}, {
+		err:  errors.Join(WithFields(errFoo, a), WithFields(errFoo, b)),
+		want: []Field{a, b},
+	}}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.err), func(t *testing.T) {
+			if got := Fields(tc.err); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+			if tc.err != nil && !errors.Is(tc.err, errFoo) {
+				t.Errorf("%v does not wrap %v", tc.err, errFoo)
+			}
+		})
+	}
+}
+
+func TestCapture(t *testing.T) {
+	called := false
+	lazy := CaptureFunc(func() []Field {
+		called = true
+		return []Field{{"n", 2}}
+	})
+	func() (err error) {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Must(nil, lazy)
+		return nil
+	}()
+	if called {
+		t.Error("CaptureFunc: function called without error")
+	}
+	err := func() (err error) {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Must(errors.New("foo"), Capture("a", 1), lazy)
+		return nil
+	}()
+	want := []Field{{"n", 2}, {"args", []interface{}{"a", 1}}}
+	if got := Fields(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+// Try1 captures the results of a call returning a value and an error, so that
+// they can be checked in a single expression:
+//
+//     data := errc.Try1(ioutil.ReadAll(r)).Must(&e)
+//
+// is equivalent to
+//
+//     data, err := ioutil.ReadAll(r)
+//     e.Must(err)
+func Try1[T any](v T, err error) Result1[T] {
+	return Result1[T]{v, err}
+}
+
+// A Result1 holds the results of a call returning a value and an error.
+type Result1[T any] struct {
+	v   T
+	err error
+}
+
+// Must calls e.Must with the error of r and the given handlers, and returns the
+// value of r if Must returns.
+func (r Result1[T]) Must(e *Catcher, h ...Handler) T {
+	e.Must(r.err, h...)
+	return r.v
+}
+
+// Try2 captures the results of a call returning two values and an error, so
+// that they can be checked in a single expression:
+//
+//     n, addr := errc.Try2(conn.ReadFrom(buf)).Must(&e)
+func Try2[A, B any](a A, b B, err error) Result2[A, B] {
+	return Result2[A, B]{a, b, err}
+}
+
+// A Result2 holds the results of a call returning two values and an error.
+type Result2[A, B any] struct {
+	a   A
+	b   B
+	err error
+}
+
+// Must calls e.Must with the error of r and the given handlers, and returns the
+// values of r if Must returns.
+func (r Result2[A, B]) Must(e *Catcher, h ...Handler) (A, B) {
+	e.Must(r.err, h...)
+	return r.a, r.b
+}
@@ -0,0 +1,50 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func div(a, b int) (int, int, error) {
+	if b == 0 {
+		return 0, 0, errors.New("division by zero")
+	}
+	return a / b, a % b, nil
+}
+
+func TestTry(t *testing.T) {
+	testCases := []struct {
+		s    string
+		b    int
+		want string
+		err  string
+	}{
+		{s: "7", b: 2, want: "3r1"},
+		{s: "x", b: 2, err: `strconv.Atoi: parsing "x": invalid syntax`},
+		{s: "7", b: 0, err: "division by zero"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			var got string
+			err := func() (err error) {
+				e := Catch(&err)
+				defer e.Handle()
+				a := Try1(strconv.Atoi(tc.s)).Must(&e)
+				q, r := Try2(div(a, tc.b)).Must(&e, identity)
+				got = strconv.Itoa(q) + "r" + strconv.Itoa(r)
+				return nil
+			}()
+			if got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+			if err != nil && err.Error() != tc.err || err == nil && tc.err != "" {
+				t.Errorf("err: got %v; want %v", err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/mpvl/errc
+
+go 1.21
+
+require (
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+	golang.org/x/tools v0.24.1
+)
+
+require (
+	golang.org/x/mod v0.20.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+)
@@ -0,0 +1,91 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+)
+
+// Goroutine returns an Option that annotates all errors recorded by the
+// Catcher with fields identifying the goroutine on which Catch was called:
+//
+//     goroutine             the goroutine id
+//     goroutine.created_by  the function and location that created it
+//     pprof.<key>           the value of each pprof label set in ctx
+//
+// The fields can be retrieved with Fields. This allows errors aggregated from
+// many concurrent workers to be traced back to the worker that produced them.
+// The ctx argument may be nil, in which case no pprof labels are added.
+func Goroutine(ctx context.Context) Option {
+	return option(func(c *core) {
+		x := c.ext()
+		id, createdBy := goroutine()
+		x.fields = append(x.fields, Field{"goroutine", id})
+		if createdBy != "" {
+			x.fields = append(x.fields, Field{"goroutine.created_by", createdBy})
+		}
+		if ctx != nil {
+			pprof.ForLabels(ctx, func(key, value string) bool {
+				x.fields = append(x.fields, Field{"pprof." + key, value})
+				return true
+			})
+		}
+	})
+}
+
+// goroutine returns the id of the current goroutine and the function and
+// location that created it.
+func goroutine() (id int64, createdBy string) {
+	buf := make([]byte, 1024)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) || len(buf) >= 1<<20 {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	return parseGoroutine(buf)
+}
+
+// goid returns the id of the current goroutine. It is cheaper than goroutine
+// as it only needs the first line of the stack trace.
+func goid() int64 {
+	var buf [64]byte
+	id, _ := parseGoroutine(buf[:runtime.Stack(buf[:], false)])
+	return id
+}
+
+// parseGoroutine extracts the goroutine id and creation site from a stack
+// trace as produced by runtime.Stack.
+func parseGoroutine(stack []byte) (id int64, createdBy string) {
+	line, rest := cutLine(stack)
+	line = bytes.TrimPrefix(line, []byte("goroutine "))
+	if i := bytes.IndexByte(line, ' '); i >= 0 {
+		id, _ = strconv.ParseInt(string(line[:i]), 10, 64)
+	}
+	i := bytes.Index(rest, []byte("\ncreated by "))
+	if i < 0 {
+		return id, ""
+	}
+	fn, rest := cutLine(rest[i+len("\ncreated by "):])
+	loc, _ := cutLine(rest)
+	loc = bytes.TrimSpace(loc)
+	if j := bytes.LastIndex(loc, []byte(" +0x")); j >= 0 {
+		loc = loc[:j]
+	}
+	return id, string(fn) + " " + string(loc)
+}
+
+func cutLine(b []byte) (line, rest []byte) {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		return b[:i], b[i+1:]
+	}
+	return b, nil
+}
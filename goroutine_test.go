@@ -0,0 +1,55 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"errors"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+func TestParseGoroutine(t *testing.T) {
+	stack := `goroutine 42 [running]:
+main.worker()
+	/src/main.go:20 +0x1d
+created by main.main in goroutine 1
+	/src/main.go:10 +0x25
+`
+	id, createdBy := parseGoroutine([]byte(stack))
+	if id != 42 {
+		t.Errorf("id: got %d; want 42", id)
+	}
+	if want := "main.main in goroutine 1 /src/main.go:10"; createdBy != want {
+		t.Errorf("createdBy: got %q; want %q", createdBy, want)
+	}
+}
+
+func TestGoroutine(t *testing.T) {
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("worker", "w1"))
+	done := make(chan error)
+	go func() {
+		done <- func() (err error) {
+			e := Catch(&err, Goroutine(ctx))
+			defer e.Handle()
+			e.Must(errors.New("foo"))
+			return nil
+		}()
+	}()
+	fields := map[string]interface{}{}
+	for _, f := range Fields(<-done) {
+		fields[f.Key] = f.Value
+	}
+	if id, _ := fields["goroutine"].(int64); id <= 0 {
+		t.Errorf("goroutine: got %v; want id > 0", fields["goroutine"])
+	}
+	if s, _ := fields["goroutine.created_by"].(string); !strings.Contains(s, "TestGoroutine") {
+		t.Errorf("goroutine.created_by: got %q", s)
+	}
+	if got := fields["pprof.worker"]; got != "w1" {
+		t.Errorf("pprof.worker: got %v; want w1", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"sync"
+)
+
+// A Group runs functions in goroutines, each with its own Catcher, and
+// collects the first error they report. It is the errc counterpart of
+// errgroup.Group:
+//
+//     g, ctx := errc.NewGroup(ctx)
+//     for _, url := range urls {
+//         url := url
+//         g.Go(func(e *errc.Catcher) error {
+//             resp, err := fetch(ctx, url)
+//             e.Must(err)
+//             e.Defer(resp.Body.Close)
+//             ...
+//             return nil
+//         })
+//     }
+//     err := g.Wait()
+//
+// Panics in the goroutines are converted to a *PanicError and reported as
+// errors instead of crashing the program. A zero Group is valid, but does not
+// cancel a context on failure.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewGroup returns a new Group and an associated context derived from ctx.
+// The derived context is canceled the first time a function passed to Go
+// reports an error or the first time Wait returns, whichever occurs first.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go calls f in a new goroutine with a Catcher created with the given handlers.
+// The Catcher is associated with the context of the Group. The deferred
+// functions registered with the Catcher are run before f's goroutine
+// completes.
+//
+// The first error reported by any of the functions, either returned, detected
+// by Must, or resulting from a panic, cancels the context of the Group and is
+// returned by Wait.
+func (g *Group) Go(f func(e *Catcher) error, h ...Handler) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := g.run(f, h); err != nil {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if g.err == nil {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel()
+				}
+			}
+		}
+	}()
+}
+
+func (g *Group) run(f func(e *Catcher) error, h []Handler) (err error) {
+	defer func() {
+		// Handle records a *PanicError before resuming the panic.
+		if r := recover(); r != nil && err == nil {
+			err = &PanicError{Value: r}
+		}
+	}()
+	e := Catch(&err, h...)
+	defer e.Handle()
+	if g.ctx != nil {
+		e.WithContext(g.ctx)
+	}
+	return f(&e)
+}
+
+// Wait blocks until all function calls from the Go method have returned, then
+// returns the first error reported by them, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel()
+	}
+	return g.err
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	var closed int32
+	errFail := errors.New("fail")
+	for i := 0; i < 10; i++ {
+		i := i
+		g.Go(func(e *Catcher) error {
+			e.Defer(func() { atomic.AddInt32(&closed, 1) })
+			if i == 3 {
+				e.Must(errFail)
+			}
+			<-ctx.Done()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != errFail {
+		t.Errorf("got %v; want %v", err, errFail)
+	}
+	if ctx.Err() == nil {
+		t.Error("context not canceled")
+	}
+	if closed != 10 {
+		t.Errorf("got %d deferred calls; want 10", closed)
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	g.Go(func(e *Catcher) error {
+		panic("boom")
+	})
+	g.Go(func(e *Catcher) error {
+		<-ctx.Done()
+		return nil
+	})
+	err := g.Wait()
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("got %v; want panic error with value boom", err)
+	}
+	if pe.Stack == nil {
+		t.Error("panic error has no stack")
+	}
+}
+
+func TestGroupZero(t *testing.T) {
+	var g Group
+	var n int32
+	for i := 0; i < 3; i++ {
+		g.Go(func(e *Catcher) error {
+			atomic.AddInt32(&n, 1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("got %v; want nil", err)
+	}
+	if n != 3 {
+		t.Errorf("got %d calls; want 3", n)
+	}
+}
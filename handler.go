@@ -5,7 +5,9 @@
 package errc
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"reflect"
 )
 
 // A Handler processes errors.
@@ -17,20 +19,71 @@ type Handler interface {
 	Handle(s State, err error) error
 }
 
-var (
-	// Discard is a handler that discards the given error, causing
-	// normal control flow to resume.
-	Discard Handler = HandlerFunc(discard)
-
-	// Fatal is handler that causes execution to halt.
-	Fatal Handler = HandlerFunc(fatal)
-)
+// Discard is a handler that discards the given error, causing
+// normal control flow to resume.
+var Discard Handler = HandlerFunc(discard)
 
 func discard(s State, err error) error { return nil }
 
-func fatal(s State, err error) error {
-	os.Exit(1)
-	return nil
+// A Wrap is a Handler that prefixes errors with its message, as in
+// "msg: err". The resulting error wraps the original error, so that it can be
+// inspected with errors.Is and errors.As.
+//
+//     e.Must(err, errc.Wrap("copy failed"))
+type Wrap string
+
+// Handle implements Handler.
+func (w Wrap) Handle(s State, err error) error {
+	return fmt.Errorf("%s: %w", string(w), err)
+}
+
+// Wrapf returns a Handler that prefixes errors with a message formatted
+// according to format and args. The message is only formatted if an error
+// occurs. The resulting error wraps the original error.
+//
+//     e.Must(err, errc.Wrapf("open %s", name))
+func Wrapf(format string, args ...interface{}) Handler {
+	return HandlerFunc(func(s State, err error) error {
+		return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err)
+	})
+}
+
+// A Priority is a Handler that assigns a priority to the errors it handles.
+// An error replaces a previously recorded error if it has a higher priority.
+// Errors have priority 0 by default. Among errors of equal priority, the first
+// one is kept.
+//
+// For instance, an error resulting from a failed Close that indicates data
+// corruption may be given precedence over a previous copy error:
+//
+//     e.Defer(w.Close, errc.Priority(1))
+//
+// A Priority passes errors on unmodified.
+type Priority int
+
+// Handle implements Handler.
+func (p Priority) Handle(s State, err error) error {
+	if st, ok := s.(*state); ok {
+		st.cur.priority = int(p)
+	}
+	return err
+}
+
+// Join is a Handler that causes the error it handles to be joined with any
+// previously recorded error using errors.Join, rather than being dropped. It
+// is typically used with Defer, so that callers can see that, for instance,
+// both a copy and a subsequent Close failed:
+//
+//     e.Defer(w.Close, errc.Join)
+//
+// Join passes errors on unmodified. See also JoinDeferErrors.
+var Join Handler = HandlerFunc(joinHandler)
+
+func joinHandler(s State, err error) error {
+	if st, ok := s.(*state); ok {
+		st.cur.join = true
+	}
+	return err
 }
 
 // The HandlerFunc type is an adapter to allow the use of ordinary functions as
@@ -42,3 +95,87 @@ type HandlerFunc func(s State, err error) error
 func (f HandlerFunc) Handle(s State, err error) error {
 	return f(s, err)
 }
+
+// If returns a Handler that passes errors for which match reports true to h.
+// Other errors are passed on unmodified.
+//
+//     e.Must(err, errc.If(os.IsNotExist, errc.Discard))
+//
+// Handlers that control how a deferred function is run, such as Retry, Timeout,
+// and NotNil, and Labels take effect before any error is known and can
+// therefore not be applied conditionally. If panics if h is or chains such a
+// handler.
+func If(match func(err error) bool, h Handler) Handler {
+	if isDeferOnly(h) {
+		panic(fmt.Errorf("errd: %T cannot be applied conditionally", h))
+	}
+	return HandlerFunc(func(s State, err error) error {
+		if match(err) {
+			return h.Handle(s, err)
+		}
+		return err
+	})
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// IsKind returns a Handler that passes errors matching target to h. Other
+// errors are passed on unmodified. The target is either an error, in which case
+// errors are matched using errors.Is, or a pointer to an interface or to a type
+// implementing error, in which case errors are matched using errors.As:
+//
+//     e.Must(err,
+//         errc.IsKind(io.EOF, errc.Discard),
+//         errc.IsKind(new(*pq.Error), errc.Wrap("query failed")))
+//
+// IsKind panics if target is neither.
+func IsKind(target interface{}, h Handler) Handler {
+	if t, ok := target.(error); ok {
+		return If(func(err error) bool { return errors.Is(err, t) }, h)
+	}
+	typ := reflect.TypeOf(target)
+	if typ == nil || typ.Kind() != reflect.Ptr ||
+		typ.Elem().Kind() != reflect.Interface && !typ.Elem().Implements(errorType) {
+		panic(fmt.Errorf("errd: IsKind target %T is not an error or a pointer to an interface or error type", target))
+	}
+	return If(func(err error) bool {
+		return errors.As(err, reflect.New(typ.Elem()).Interface())
+	}, h)
+}
+
+// Chain returns a Handler that passes errors through the given handlers in
+// order, as if they were passed to Must or Defer. It stops at the first handler
+// that discards the error. Chain allows a sequence of handlers to be used where
+// a single Handler is expected, as with If. A Chain passed to Defer is
+// expanded into its handlers, so that, for instance, a Retry or Label in a
+// Chain takes effect.
+func Chain(h ...Handler) Handler {
+	return chain(h)
+}
+
+type chain []Handler
+
+func (c chain) Handle(s State, err error) error {
+	for _, h := range c {
+		if err = h.Handle(s, err); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// isDeferOnly reports whether h is, or chains, a Handler that only takes effect
+// when passed to Defer directly.
+func isDeferOnly(h Handler) bool {
+	switch x := h.(type) {
+	case runner, Label:
+		return true
+	case chain:
+		for _, h := range x {
+			if isDeferOnly(h) {
+				return true
+			}
+		}
+	}
+	return false
+}
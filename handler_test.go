@@ -5,8 +5,10 @@
 package errc
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 type intErr int
@@ -181,3 +183,211 @@ func BenchmarkFuncOption(b *testing.B) {
 		}))
 	}
 }
+
+func TestPriority(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		handler []Handler
+		want    error
+	}{{
+		desc: "first wins",
+		want: err1,
+	}, {
+		desc:    "equal priority",
+		handler: []Handler{Priority(0)},
+		want:    err1,
+	}, {
+		desc:    "higher priority",
+		handler: []Handler{Priority(1)},
+		want:    err2,
+	}, {
+		desc:    "lower priority",
+		handler: []Handler{Priority(-1)},
+		want:    err1,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := func() (err error) {
+				e := Catch(&err)
+				defer e.Handle()
+				e.Defer(func() error { return err2 }, tc.handler...)
+				e.Must(err1)
+				return nil
+			}()
+			if got != tc.want {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		options []Handler
+		handler []Handler
+		want    string
+	}{{
+		desc: "first wins",
+		want: "1",
+	}, {
+		desc:    "join handler",
+		handler: []Handler{Join},
+		want:    "1\n3\n2",
+	}, {
+		desc:    "join option",
+		options: []Handler{JoinDeferErrors},
+		want:    "1\n3\n2",
+	}, {
+		desc:    "discard",
+		options: []Handler{JoinDeferErrors},
+		handler: []Handler{Discard},
+		want:    "1",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := func() (err error) {
+				e := Catch(&err, tc.options...)
+				defer e.Handle()
+				e.Defer(func() error { return err2 }, tc.handler...)
+				e.Defer(func() error { return err3 }, tc.handler...)
+				e.Must(err1)
+				return nil
+			}()
+			if got.Error() != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	testCases := []struct {
+		h    Handler
+		want string
+	}{
+		{Wrap("copy failed"), "copy failed: 1"},
+		{Wrapf("open %s", "foo"), "open foo: 1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			got := func() (err error) {
+				e := Catch(&err)
+				defer e.Handle()
+				e.Must(err1, tc.h)
+				return nil
+			}()
+			if got.Error() != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+			if !errors.Is(got, err1) {
+				t.Errorf("%v does not wrap %v", got, err1)
+			}
+		})
+	}
+}
+
+func TestPanicUnwrap(t *testing.T) {
+	errFoo := errors.New("foo")
+	var err error
+	func() {
+		defer func() { recover() }()
+		e := Catch(&err)
+		defer e.Handle()
+		panic(fmt.Errorf("wrapped: %w", errFoo))
+	}()
+	if !errors.Is(err, errFoo) {
+		t.Errorf("%v does not wrap %v", err, errFoo)
+	}
+}
+
+type kindErr struct{ msg string }
+
+func (e *kindErr) Error() string { return e.msg }
+
+func TestMatchers(t *testing.T) {
+	wrapped := fmt.Errorf("wrapped: %w", err1)
+	kind := fmt.Errorf("wrapped: %w", &kindErr{"kind"})
+	testCases := []struct {
+		desc string
+		h    Handler
+		err  error
+		want string // empty if discarded
+	}{
+		{"if match", If(func(err error) bool { return err == err1 }, Discard), err1, ""},
+		{"if no match", If(func(err error) bool { return err == err1 }, Discard), err2, "2"},
+		{"is", IsKind(err1, Discard), wrapped, ""},
+		{"is no match", IsKind(err2, Discard), wrapped, "wrapped: 1"},
+		{"as", IsKind(new(*kindErr), Wrap("kind")), kind, "kind: wrapped: kind"},
+		{"as no match", IsKind(new(*kindErr), Discard), wrapped, "wrapped: 1"},
+		{"as interface", IsKind(new(interface{ Unwrap() error }), Discard), wrapped, ""},
+		{"chain", Chain(Wrap("a"), Wrap("b")), err1, "b: a: 1"},
+		{"chain discard", Chain(Discard, Wrap("b")), err1, ""},
+		{"chain in if", IsKind(err1, Chain(Wrap("a"), Wrap("b"))), err1, "b: a: 1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := func() (err error) {
+				e := Catch(&err)
+				defer e.Handle()
+				e.Must(tc.err, tc.h)
+				return nil
+			}()
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsKindInvalid(t *testing.T) {
+	for _, target := range []interface{}{nil, 1, new(int)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("IsKind(%T) did not panic", target)
+				}
+			}()
+			IsKind(target, Discard)
+		}()
+	}
+}
+
+func TestChainDefer(t *testing.T) {
+	calls := 0
+	var pending []string
+	Run(func(e *Catcher) error {
+		e.Defer(func() error {
+			calls++
+			return errors.New("fail")
+		}, Chain(Retry(3, time.Millisecond), Chain(Label("flaky"))), Discard)
+		e.Defer(func(s State) error {
+			pending = s.Pending()
+			return nil
+		})
+		return nil
+	})
+	if calls != 4 {
+		t.Errorf("calls: got %d; want 4", calls)
+	}
+	if len(pending) != 1 || pending[0] != "flaky" {
+		t.Errorf("pending: got %q; want [flaky]", pending)
+	}
+}
+
+func TestIfDeferOnly(t *testing.T) {
+	for _, h := range []Handler{Retry(1, 0), Label("x"), Chain(Discard, Timeout(time.Second))} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%T: If did not panic", h)
+				}
+			}()
+			If(func(error) bool { return true }, h)
+		}()
+	}
+}
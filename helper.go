@@ -0,0 +1,67 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "errors"
+
+// A Helper gives a helper type access to the Catcher of its caller, allowing
+// it to check errors and register defers in the caller's scope. Unlike a
+// plain *Catcher, a Helper checks that it is only used while the Catcher is
+// live: using a Helper after it was detached or after the Catcher ran Handle
+// panics.
+//
+// A Helper is typically obtained by a constructor of the helper type:
+//
+//     func newConn(e *errc.Catcher, addr string) *conn {
+//         c := &conn{h: e.Helper()}
+//         c.h.Defer(c.close)
+//         return c
+//     }
+type Helper struct {
+	e   *Catcher
+	gen uint32 // generation of e for which h was created
+}
+
+var (
+	errDetached = errors.New("errd: use of detached Helper")
+	errHandled  = errors.New("errd: use of Helper after Handle")
+)
+
+// Helper returns a new Helper for e.
+func (e *Catcher) Helper() *Helper {
+	return &Helper{e, e.gen}
+}
+
+func (h *Helper) catcher() *Catcher {
+	switch {
+	case h.e == nil:
+		panic(errDetached)
+	case h.e.done, h.e.gen != h.gen:
+		panic(errHandled)
+	}
+	return h.e
+}
+
+// Must calls Must on the Catcher of h.
+func (h *Helper) Must(err error, hs ...Handler) {
+	h.catcher().Must(err, hs...)
+}
+
+// Defer calls Defer on the Catcher of h.
+func (h *Helper) Defer(x interface{}, hs ...Handler) {
+	h.catcher().Defer(x, hs...)
+}
+
+// Detach releases h from its Catcher. Defers registered through h remain
+// registered with the Catcher. Any subsequent use of h panics.
+func (h *Helper) Detach() {
+	h.e = nil
+}
+
+// Attached reports whether h can still be used: it has not been detached and
+// its Catcher has not completed Handle.
+func (h *Helper) Attached() bool {
+	return h.e != nil && !h.e.done && h.e.gen == h.gen
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHelper(t *testing.T) {
+	errFoo := errors.New("foo")
+	var h *Helper
+	var result string
+	err := func() (err error) {
+		e := Catch(&err)
+		defer e.Handle()
+		h = e.Helper()
+		h.Defer(func() { result += "deferred" })
+		if !h.Attached() {
+			t.Error("Attached: got false; want true")
+		}
+		h.Must(errFoo)
+		return nil
+	}()
+	if err != errFoo {
+		t.Errorf("err: got %v; want %v", err, errFoo)
+	}
+	if result != "deferred" {
+		t.Errorf("result: got %q; want %q", result, "deferred")
+	}
+	if h.Attached() {
+		t.Error("Attached: got true; want false")
+	}
+	testPanic(t, errHandled, func() { h.Must(nil) })
+
+	h.Detach()
+	testPanic(t, errDetached, func() { h.Defer(func() {}) })
+}
+
+func testPanic(t *testing.T, want interface{}, f func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != want {
+			t.Errorf("got panic %v; want %v", r, want)
+		}
+	}()
+	f()
+}
+
+func TestHelperPooled(t *testing.T) {
+	var h *Helper
+	func() {
+		var err error
+		e := Pooled(&err)
+		defer e.Handle()
+		h = e.Helper()
+	}()
+	// The Catcher may be reused by another call after it was released.
+	var err error
+	e := Pooled(&err)
+	defer e.Handle()
+	if h.Attached() {
+		t.Error("Helper of a released Catcher is still attached")
+	}
+	defer func() {
+		if r := recover(); r != errHandled {
+			t.Errorf("got %v; want %v", r, errHandled)
+		}
+	}()
+	h.Must(nil)
+}
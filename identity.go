@@ -0,0 +1,67 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "context"
+
+// An Identity identifies on whose behalf an operation is performed.
+type Identity struct {
+	User    string
+	Tenant  string
+	Session string
+}
+
+// An IdentityProvider supplies the Identity associated with a context.
+type IdentityProvider interface {
+	Identity(ctx context.Context) Identity
+}
+
+// The IdentityProviderFunc type is an adapter to allow the use of ordinary
+// functions as IdentityProviders.
+type IdentityProviderFunc func(ctx context.Context) Identity
+
+// Identity calls f(ctx).
+func (f IdentityProviderFunc) Identity(ctx context.Context) Identity {
+	return f(ctx)
+}
+
+type providerKey struct{}
+
+// WithIdentityProvider returns a copy of ctx that carries p. Identify uses p to
+// annotate errors of Catchers created with CatchContext for the returned
+// context or any context derived from it.
+func WithIdentityProvider(ctx context.Context, p IdentityProvider) context.Context {
+	return context.WithValue(ctx, providerKey{}, p)
+}
+
+// Identify is a Handler that annotates errors with the identity supplied by
+// the IdentityProvider of State.Context. It adds the fields "user", "tenant",
+// and "session" for each non-empty value. Passing Identify as a default handler
+// to CatchContext gives consistent attribution in error logs without
+// per-call-site code:
+//
+//     e := errc.CatchContext(ctx, &err, errc.Identify)
+//
+// Identify passes errors on unmodified if there is no IdentityProvider.
+var Identify Handler = HandlerFunc(identify)
+
+func identify(s State, err error) error {
+	p, ok := s.Context().Value(providerKey{}).(IdentityProvider)
+	if !ok {
+		return err
+	}
+	id := p.Identity(s.Context())
+	var fields []Field
+	for _, f := range []Field{
+		{"user", id.User},
+		{"tenant", id.Tenant},
+		{"session", id.Session},
+	} {
+		if f.Value != "" {
+			fields = append(fields, f)
+		}
+	}
+	return WithFields(err, fields...)
+}
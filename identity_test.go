@@ -0,0 +1,43 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestIdentify(t *testing.T) {
+	p := IdentityProviderFunc(func(ctx context.Context) Identity {
+		return Identity{User: "gopher", Tenant: "acme"}
+	})
+	testCases := []struct {
+		desc string
+		ctx  context.Context
+		want []Field
+	}{{
+		desc: "no provider",
+		ctx:  context.Background(),
+	}, {
+		desc: "provider",
+		ctx:  WithIdentityProvider(context.Background(), p),
+		want: []Field{{"user", "gopher"}, {"tenant", "acme"}},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := func() (err error) {
+				e := CatchContext(tc.ctx, &err, Identify)
+				defer e.Handle()
+				e.Must(errors.New("foo"))
+				return nil
+			}()
+			if got := Fields(err); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "errors"
+
+// NotNil returns a Handler that guarantees that a deferred function taking the
+// current error, such as a CloseWithError method, is only passed a nil error
+// if the function completed successfully. If the function is panicking or Must
+// detected an error, but no error is recorded, for instance because a
+// PanicHandler discarded the panic, the deferred function is passed sentinel
+// instead:
+//
+//     e.Defer(w.CloseWithError, errc.NotNil(context.Canceled))
+//
+// The sentinel is only reported to the deferred function, as the result of
+// State.Err, and is not recorded itself. NotNil has no effect when passed to
+// Must and passes errors on unmodified. It panics if sentinel is nil.
+func NotNil(sentinel error) Handler {
+	if sentinel == nil {
+		panic(errNilSentinel)
+	}
+	return &notNil{sentinel}
+}
+
+var errNilSentinel = errors.New("errd: nil sentinel passed to NotNil")
+
+type notNil struct {
+	sentinel error
+}
+
+// Handle implements Handler.
+func (n *notNil) Handle(s State, err error) error { return err }
+
+func (n *notNil) run(s State, label string, f func(s State) error) error {
+	switch st := s.(type) {
+	case *state:
+		if st.Err() == nil && (st.inPanic || st.failed) {
+			x := st.ext()
+			saved := x.sentinel
+			x.sentinel = n.sentinel
+			defer func() { x.sentinel = saved }()
+		}
+	case *snapshot:
+		if st.err == nil && (st.panicking || st.failed) {
+			c := *st
+			c.err = n.sentinel
+			s = &c
+		}
+	}
+	return f(s)
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNotNil(t *testing.T) {
+	errFail := errors.New("fail")
+	swallow := HandlePanics(PanicHandlerFunc(func(s State, p *PanicError) (error, bool) {
+		return nil, false
+	}))
+	testCases := []struct {
+		desc string
+		opts []Handler
+		f    func(e *Catcher)
+		got  error // error passed to the deferred function
+	}{{
+		desc: "success",
+		f:    func(e *Catcher) {},
+	}, {
+		desc: "failure",
+		f:    func(e *Catcher) { e.Must(errFail) },
+		got:  errFail,
+	}, {
+		desc: "discarded",
+		f:    func(e *Catcher) { e.Must(errFail, Discard) },
+	}, {
+		desc: "panic",
+		opts: []Handler{HandlePanics(Recover)},
+		f:    func(e *Catcher) { panic("boom") },
+		got:  &PanicError{},
+	}, {
+		desc: "swallowed panic",
+		opts: []Handler{swallow},
+		f:    func(e *Catcher) { panic("boom") },
+		got:  context.Canceled,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got, after error
+			called := false
+			err := Run(func(e *Catcher) error {
+				e.Defer(func(s State) error {
+					after = s.Err()
+					return nil
+				})
+				e.Defer(func(err error) error {
+					got, called = err, true
+					return nil
+				}, NotNil(context.Canceled))
+				tc.f(e)
+				return nil
+			}, tc.opts...)
+			if !called {
+				t.Fatal("deferred function not called")
+			}
+			if _, ok := tc.got.(*PanicError); ok {
+				if _, ok := got.(*PanicError); !ok {
+					t.Errorf("got %v; want *PanicError", got)
+				}
+			} else if got != tc.got {
+				t.Errorf("got %v; want %v", got, tc.got)
+			}
+			if after != err {
+				t.Errorf("sentinel leaked: got %v; want %v", after, err)
+			}
+		})
+	}
+}
+
+func TestNotNilTimeout(t *testing.T) {
+	var got error
+	Run(func(e *Catcher) error {
+		e.Defer(func(err error) error {
+			got = err
+			return nil
+		}, Timeout(time.Hour), NotNil(context.Canceled))
+		panic("boom")
+	}, HandlePanics(PanicHandlerFunc(func(s State, p *PanicError) (error, bool) {
+		return nil, false
+	})))
+	if got != context.Canceled {
+		t.Errorf("got %v; want %v", got, context.Canceled)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "sync/atomic"
+
+// An Observer is notified of the errors recorded by Catchers. It can be used to
+// export metrics or traces for all functions using package errc.
+//
+// An Observer is installed on a Catcher with Observe or package-wide with
+// SetObserver. Its methods are called synchronously and must not call methods
+// of the Catcher.
+type Observer interface {
+	// OnError is called for an error detected by Must that was not
+	// discarded by its handlers. It is passed the error returned by the
+	// handlers.
+	OnError(s State, err error)
+
+	// OnDeferError is called for an error returned by a deferred function
+	// that was not discarded by its handlers. It is passed the error returned
+	// by the handlers.
+	OnDeferError(s State, err error)
+
+	// OnPanic is called when a panic is caught by Handle, before the deferred
+	// functions are run.
+	OnPanic(s State, err *PanicError)
+}
+
+// Observe returns an Option that installs o on a Catcher. It takes precedence
+// over an Observer installed with SetObserver.
+func Observe(o Observer) Option {
+	return option(func(c *core) { c.ext().observer = o })
+}
+
+var defaultObserver atomic.Value // holds observerBox
+
+// observerBox allows storing a nil Observer in an atomic.Value.
+type observerBox struct{ Observer }
+
+// SetObserver installs o as the Observer for all Catchers that do not have an
+// Observer installed with Observe. A nil o removes the package-wide Observer.
+// It is safe to call SetObserver concurrently with the use of Catchers.
+func SetObserver(o Observer) {
+	defaultObserver.Store(observerBox{o})
+}
+
+// observer returns the Observer for e, or nil if there is none.
+func observer(e *Catcher) Observer {
+	if e.x != nil && e.x.observer != nil {
+		return e.x.observer
+	}
+	b, _ := defaultObserver.Load().(observerBox)
+	return b.Observer
+}
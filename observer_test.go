@@ -0,0 +1,54 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type events []string
+
+func (ev *events) OnError(s State, err error)       { *ev = append(*ev, "error:"+err.Error()) }
+func (ev *events) OnDeferError(s State, err error)  { *ev = append(*ev, "defer:"+err.Error()) }
+func (ev *events) OnPanic(s State, err *PanicError) { *ev = append(*ev, "panic:"+err.Error()) }
+
+func TestObserve(t *testing.T) {
+	ev := &events{}
+	Run(func(e *Catcher) error {
+		e.Defer(func() error { return errors.New("close") })
+		e.Defer(func() error { return errors.New("ignored") }, Discard)
+		e.Must(errors.New("ignored"), Discard)
+		e.Must(errors.New("must"))
+		return nil
+	}, Observe(ev))
+	want := events{"error:must", "defer:close"}
+	if !reflect.DeepEqual(*ev, want) {
+		t.Errorf("got %q; want %q", *ev, want)
+	}
+}
+
+func TestSetObserver(t *testing.T) {
+	global, local := &events{}, &events{}
+	SetObserver(global)
+	defer SetObserver(nil)
+	func() {
+		defer func() { recover() }()
+		Run(func(e *Catcher) error {
+			panic("boom")
+		})
+	}()
+	Run(func(e *Catcher) error {
+		e.Must(errors.New("local"))
+		return nil
+	}, Observe(local))
+	if want := (events{"panic:errd: paniced: boom"}); !reflect.DeepEqual(*global, want) {
+		t.Errorf("global: got %q; want %q", *global, want)
+	}
+	if want := (events{"error:local"}); !reflect.DeepEqual(*local, want) {
+		t.Errorf("local: got %q; want %q", *local, want)
+	}
+}
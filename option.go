@@ -0,0 +1,150 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// An Option configures a Catcher. Options are passed to Catch along with the
+// default handlers. An Option is also a Handler, but it has no effect when it
+// is passed to Must or Defer.
+type Option interface {
+	Handler
+	apply(c *core)
+}
+
+// Collect is an Option that causes Must to record errors and continue instead
+// of returning from the function. All recorded errors, including those of
+// deferred functions, are joined using errors.Join. This is useful for
+// validation and batch cleanup, where all failures should be reported.
+//
+//     func validate(c *Config) (err error) {
+//         e := errc.Catch(&err, errc.Collect)
+//         defer e.Handle()
+//
+//         e.Must(checkName(c.Name))
+//         e.Must(checkAddr(c.Addr))
+//         return nil
+//     }
+//
+// Upon return, the collected errors are joined with any error returned by the
+// function, rather than being replaced by it. Priorities assigned with Priority
+// have no effect on a collecting Catcher. An error resulting from a panic
+// replaces all collected errors.
+var Collect Option = option(func(c *core) { c.collect = true })
+
+// JoinDeferErrors is an Option that causes errors from deferred functions to be
+// joined with any previously recorded error, as if Join were passed to each
+// call to Defer.
+var JoinDeferErrors Option = option(func(c *core) { c.joinDefers = true })
+
+// option is the implementation of all Options in this package.
+type option func(c *core)
+
+func (o option) apply(c *core) { o(c) }
+
+// Handle implements Handler. It passes errors on unmodified.
+func (o option) Handle(s State, err error) error { return err }
+
+// A Config is an Option that bundles the configuration shared by the Catchers
+// of a package, overriding the handlers installed with SetDefaultHandlers:
+//
+//     var config = errc.Config{
+//         Handlers: []errc.Handler{errc.Wrap("store")},
+//         Options:  []errc.Option{errc.RecordCallers},
+//     }
+//
+//     func (s *Store) Put(key string, v []byte) (err error) {
+//         e := errc.Catch(&err, config)
+//         defer e.Handle()
+//         ...
+//     }
+//
+// Handlers are used with the following precedence: the handlers passed to Must
+// or Defer, the handlers passed to Catch, those of a Config passed to Catch,
+// and finally those installed with SetDefaultHandlers.
+type Config struct {
+	// Handlers are the default handlers for Catchers that are not passed any
+	// handlers. If nil, the handlers installed with SetDefaultHandlers are
+	// used. A non-nil empty slice disables them.
+	Handlers []Handler
+
+	// Options are applied to each Catcher, before any Options passed to Catch
+	// after the Config.
+	Options []Option
+}
+
+func (c Config) apply(x *core) {
+	if c.Handlers != nil {
+		x.ext().handlers = c.Handlers
+	}
+	for _, o := range c.Options {
+		o.apply(x)
+	}
+}
+
+// Handle implements Handler. It passes errors on unmodified.
+func (c Config) Handle(s State, err error) error { return err }
+
+var globalHandlers atomic.Value // holds []Handler
+
+var errGlobalOption = errors.New("errd: Option passed to SetDefaultHandlers")
+
+// SetDefaultHandlers installs h as the default handlers for all Catchers
+// created afterwards that are not passed any handlers, either directly or
+// through a Config. This allows an application to install organization-wide
+// behavior, such as emitting metrics for all errors, without repeating the
+// handlers at every call to Catch. SetDefaultHandlers panics if h contains an
+// Option. It is safe to call SetDefaultHandlers concurrently with the use of
+// Catchers, but it is typically called once during initialization.
+func SetDefaultHandlers(h ...Handler) {
+	for _, x := range h {
+		if _, ok := x.(Option); ok {
+			panic(errGlobalOption)
+		}
+	}
+	globalHandlers.Store(append([]Handler(nil), h...))
+}
+
+// configure applies the Options in h to c and returns the remaining handlers
+// or, if there are none, the default handlers.
+func configure(c *core, h []Handler) []Handler {
+	n := 0
+	for _, x := range h {
+		if _, ok := x.(Option); ok {
+			n++
+		}
+	}
+	if n == 0 {
+		if len(h) == 0 {
+			return defaultHandlers(c)
+		}
+		return h
+	}
+	handlers := make([]Handler, 0, len(h)-n)
+	for _, x := range h {
+		if o, ok := x.(Option); ok {
+			o.apply(c)
+		} else {
+			handlers = append(handlers, x)
+		}
+	}
+	if len(handlers) == 0 {
+		return defaultHandlers(c)
+	}
+	return handlers
+}
+
+// defaultHandlers returns the handlers of a Config applied to c or, if there
+// is none, the handlers installed with SetDefaultHandlers.
+func defaultHandlers(c *core) []Handler {
+	if c.x != nil && c.x.handlers != nil {
+		return c.x.handlers
+	}
+	h, _ := globalHandlers.Load().([]Handler)
+	return h
+}
@@ -0,0 +1,143 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	var report *reporter
+	got := func() (err error) {
+		e := Catch(&err, inc, ReportTo(&reports{}), inc)
+		defer e.Handle()
+		report = e.x.report
+		if len(e.defaultHandlers) != 2 {
+			t.Errorf("got %d default handlers; want 2", len(e.defaultHandlers))
+		}
+		e.Must(err2, Collect, Discard) // Option has no effect
+		e.Must(err1)
+		return nil
+	}()
+	if got != err3 {
+		t.Errorf("got %v; want %v", got, err3)
+	}
+	if report == nil {
+		t.Error("option not applied")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	errs := []error{errors.New("a"), errors.New("b"), errors.New("c"), errors.New("d")}
+	var reached bool
+	err := func() (err error) {
+		e := Catch(&err, Collect)
+		defer e.Handle()
+		e.Defer(func() error { return errs[3] })
+		e.Must(errs[0])
+		e.Must(errors.New("discarded"), Discard)
+		e.Must(errs[1])
+		e.Must(nil)
+		e.Must(errs[2], Priority(1))
+		reached = true
+		return nil
+	}()
+	if !reached {
+		t.Error("Must returned from function")
+	}
+	if want := "a\nb\nc\nd"; err == nil || err.Error() != want {
+		t.Errorf("got %q; want %q", err, want)
+	}
+	for _, x := range errs {
+		if !errors.Is(err, x) {
+			t.Errorf("%v not in joined error", x)
+		}
+	}
+}
+
+func TestCollectReturn(t *testing.T) {
+	errA := errors.New("a")
+	errRet := errors.New("ret")
+	err := func() (err error) {
+		e := Catch(&err, Collect)
+		defer e.Handle()
+		e.Must(errA)
+		return errRet
+	}()
+	if want := "a\nret"; err == nil || err.Error() != want {
+		t.Errorf("got %q; want %q", err, want)
+	}
+}
+
+func TestDefaultHandlers(t *testing.T) {
+	SetDefaultHandlers(Wrap("global"))
+	defer SetDefaultHandlers()
+	errFail := errors.New("fail")
+	testCases := []struct {
+		desc  string
+		catch []Handler
+		must  []Handler
+		want  string
+	}{{
+		desc: "global",
+		want: "global: fail",
+	}, {
+		desc:  "global with options",
+		catch: []Handler{Collect},
+		want:  "global: fail",
+	}, {
+		desc:  "config",
+		catch: []Handler{Config{Handlers: []Handler{Wrap("config")}}},
+		want:  "config: fail",
+	}, {
+		desc:  "config without handlers",
+		catch: []Handler{Config{Options: []Option{Collect}}},
+		want:  "global: fail",
+	}, {
+		desc:  "config disables global",
+		catch: []Handler{Config{Handlers: []Handler{}}},
+		want:  "fail",
+	}, {
+		desc:  "catch",
+		catch: []Handler{Config{Handlers: []Handler{Wrap("config")}}, Wrap("catch")},
+		want:  "catch: fail",
+	}, {
+		desc:  "per call",
+		catch: []Handler{Wrap("catch")},
+		must:  []Handler{Wrap("call")},
+		want:  "call: fail",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := func() (err error) {
+				e := Catch(&err, tc.catch...)
+				defer e.Handle()
+				e.Must(errFail, tc.must...)
+				return nil
+			}()
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("got %v; want %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	var err error
+	e := Catch(&err, Config{Options: []Option{Collect, JoinDeferErrors}})
+	if !e.collect || !e.joinDefers {
+		t.Error("options of Config not applied")
+	}
+}
+
+func TestSetDefaultHandlersOption(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errGlobalOption {
+			t.Errorf("got %v; want %v", r, errGlobalOption)
+		}
+	}()
+	SetDefaultHandlers(Collect)
+}
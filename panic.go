@@ -0,0 +1,42 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+// A PanicHandler decides how Handle treats a panic. By default, a panic is
+// recorded as a *PanicError and resumed once all deferred functions have run.
+type PanicHandler interface {
+	// HandlePanic is called when Handle recovers a panic, before the deferred
+	// functions are run. It returns the error to record instead of p and
+	// whether the panic should be resumed. If the panic is not resumed, the
+	// function returns normally with the returned error, which may be nil.
+	HandlePanic(s State, p *PanicError) (err error, repanic bool)
+}
+
+// The PanicHandlerFunc type is an adapter to allow the use of ordinary
+// functions as PanicHandlers.
+type PanicHandlerFunc func(s State, p *PanicError) (err error, repanic bool)
+
+// HandlePanic calls f(s, p).
+func (f PanicHandlerFunc) HandlePanic(s State, p *PanicError) (err error, repanic bool) {
+	return f(s, p)
+}
+
+// Recover is a PanicHandler that converts panics to errors. A function using
+// it returns the *PanicError instead of panicking, which is useful for servers
+// that must not crash. Combine with a PanicHandlerFunc to be more selective,
+// for instance to resume panics resulting from runtime errors:
+//
+//     errc.HandlePanics(errc.PanicHandlerFunc(func(s errc.State, p *errc.PanicError) (error, bool) {
+//         _, isRuntime := p.Value.(runtime.Error)
+//         return p, isRuntime
+//     }))
+var Recover PanicHandler = PanicHandlerFunc(recoverPanic)
+
+func recoverPanic(s State, p *PanicError) (error, bool) { return p, false }
+
+// HandlePanics returns an Option that causes panics to be passed to h.
+func HandlePanics(h PanicHandler) Option {
+	return option(func(c *core) { c.ext().panicHandler = h })
+}
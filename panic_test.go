@@ -0,0 +1,83 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestHandlePanics(t *testing.T) {
+	errConverted := errors.New("converted")
+	selective := PanicHandlerFunc(func(s State, p *PanicError) (error, bool) {
+		if _, ok := p.Value.(runtime.Error); ok {
+			return p, true
+		}
+		return errConverted, false
+	})
+	testCases := []struct {
+		desc    string
+		h       PanicHandler
+		f       func()
+		err     string
+		repanic bool
+	}{{
+		desc: "recover",
+		h:    Recover,
+		f:    func() { panic("boom") },
+		err:  "errd: paniced: boom",
+	}, {
+		desc: "translate",
+		h:    selective,
+		f:    func() { panic("boom") },
+		err:  "converted",
+	}, {
+		desc: "rethrow runtime error",
+		h:    selective,
+		f: func() {
+			var m map[string]int
+			m["a"] = 1
+		},
+		err:     "assignment to entry in nil map",
+		repanic: true,
+	}, {
+		desc: "swallow",
+		h: PanicHandlerFunc(func(s State, p *PanicError) (error, bool) {
+			return nil, false
+		}),
+		f: func() { panic("boom") },
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var err error
+			deferred := false
+			repanic := false
+			func() {
+				defer func() { repanic = recover() != nil }()
+				e := Catch(&err, HandlePanics(tc.h))
+				defer e.Handle()
+				e.Defer(func(s State) error {
+					deferred = s.Panicking()
+					return nil
+				})
+				tc.f()
+			}()
+			if repanic != tc.repanic {
+				t.Errorf("panic resumed: got %v; want %v", repanic, tc.repanic)
+			}
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.err {
+				t.Errorf("got %q; want %q", got, tc.err)
+			}
+			if !deferred {
+				t.Error("deferred function not run while panicking")
+			}
+		})
+	}
+}
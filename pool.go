@@ -0,0 +1,78 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "sync"
+
+var catcherPool = sync.Pool{
+	New: func() interface{} {
+		e := &Catcher{}
+		e.deferred = e.buf[:0]
+		return e
+	},
+}
+
+// Pooled is like Catch, but returns a Catcher obtained from a pool. The
+// Catcher is returned to the pool when its Handle completes, after which it
+// must no longer be used:
+//
+//     e := errc.Pooled(&err)
+//     defer e.Handle()
+//
+// Pooled Catchers retain the memory they allocated for deferred functions,
+// which means that functions deferring more than a few values do not allocate
+// in steady state. Note that, as with Catch, err escapes to the heap. Hot paths
+// that want to avoid allocations altogether should use an error variable that
+// outlives the call, for instance one stored in a per-request struct.
+//
+// A Catcher whose Handle is resuming a panic is not returned to the pool.
+func Pooled(err *error, h ...Handler) *Catcher {
+	e := catcherPool.Get().(*Catcher)
+	e.err = err
+	e.defaultHandlers = configure(&e.core, h)
+	e.pooled = true
+	return e
+}
+
+// Reset reinitializes e as if it were created by Catch(err, h...), reusing
+// memory allocated by prior uses of e. It must not be called while e is in use
+// and not on a Catcher obtained from Pooled whose Handle has returned.
+func (e *Catcher) Reset(err *error, h ...Handler) {
+	pooled := e.pooled
+	e.reset()
+	e.err = err
+	e.defaultHandlers = configure(&e.core, h)
+	e.pooled = pooled
+}
+
+// reset clears the state of e, retaining a grown defer stack and the extra
+// state, if any. It invalidates the Helpers of e.
+func (e *Catcher) reset() {
+	deferred, x, gen := e.deferred, e.x, e.gen
+	e.core = core{}
+	e.gen = gen + 1
+	if cap(deferred) > bufSize {
+		// Clear stale entries so that they can be garbage collected.
+		deferred = deferred[:cap(deferred)]
+		for i := range deferred {
+			deferred[i] = deferData{}
+		}
+		e.deferred = deferred[:0]
+	} else {
+		e.deferred = e.buf[:0]
+	}
+	if x != nil {
+		*x = extra{}
+		e.x = x
+	}
+}
+
+// release returns e to the pool if it was obtained from Pooled.
+func release(e *Catcher) {
+	if e.pooled {
+		e.reset()
+		catcherPool.Put(e)
+	}
+}
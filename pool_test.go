@@ -0,0 +1,82 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPooledAllocs(t *testing.T) {
+	closers := make([]closer, 4)
+	for i := range closers {
+		closers[i] = closer{new(string)}
+	}
+	var err error
+	for n := 1; n <= len(closers); n++ {
+		f := func() {
+			e := Pooled(&err)
+			defer e.Handle()
+			for i := 0; i < n; i++ {
+				e.DeferClose(&closers[i], identity)
+			}
+		}
+		f() // warm up the pool
+		if got := testing.AllocsPerRun(100, f); got != 0 {
+			t.Errorf("%d defers: got %v allocs; want 0", n, got)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	var e Catcher
+	errFail := errors.New("fail")
+	use := func(n int, fail error) (err error, ran int) {
+		e.Reset(&err, Collect)
+		defer e.Handle()
+		for i := 0; i < n; i++ {
+			e.Defer(func() { ran++ })
+		}
+		e.Must(fail)
+		return nil, 0
+	}
+	if err, ran := use(5, errFail); !errors.Is(err, errFail) || ran != 5 {
+		t.Errorf("first use: got %v, %d; want %v, 5", err, ran, errFail)
+	}
+	if cap(e.deferred) <= bufSize {
+		t.Fatalf("got cap %d; want > %d", cap(e.deferred), bufSize)
+	}
+	if err, ran := use(2, nil); err != nil || ran != 2 {
+		t.Errorf("second use: got %v, %d; want <nil>, 2", err, ran)
+	}
+	p := &e.deferred[:cap(e.deferred)][0]
+	e.Reset(new(error))
+	for i, d := range e.deferred[:cap(e.deferred)] {
+		if d.x != nil || d.f != nil {
+			t.Errorf("%d: stale defer entry %v", i, d)
+		}
+	}
+	if &e.deferred[:1][0] != p {
+		t.Error("defer stack not retained")
+	}
+	if e.x == nil || e.x.errs != nil || e.collect {
+		t.Error("extra state not reset")
+	}
+}
+
+func TestPooledPanic(t *testing.T) {
+	var e *Catcher
+	func() {
+		defer func() { recover() }()
+		var err error
+		e = Pooled(&err)
+		defer e.Handle()
+		e.Defer(func() {})
+		panic("boom")
+	}()
+	if !e.pooled || !e.done {
+		t.Error("Catcher resuming a panic was returned to the pool")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// A Report describes the error handling of a single Catcher. It is produced at
+// the end of Handle for Catchers configured with ReportTo.
+//
+// The JSON encoding of a Report is described by the JSON schema in
+// report.schema.json, which is shipped with this package.
+type Report struct {
+	// Error is the final error, or the empty string if there was none.
+	Error string `json:"error,omitempty"`
+
+	// Panic reports whether the function panicked.
+	Panic bool `json:"panic"`
+
+	// Start is the time at which the Catcher was created.
+	Start time.Time `json:"start"`
+
+	// Duration is the time elapsed from the creation of the Catcher until the
+	// completion of Handle.
+	Duration time.Duration `json:"duration"`
+
+	// Attempts lists all errors detected by Must or returned by deferred
+	// functions and any panic caught by Handle, in the order in which they
+	// occurred.
+	Attempts []Attempt `json:"attempts,omitempty"`
+
+	// Defers lists the outcome of all deferred functions, in the order in
+	// which they were run.
+	Defers []DeferOutcome `json:"defers,omitempty"`
+
+	// Stats holds the statistics of the Catcher.
+	Stats Stats `json:"stats"`
+}
+
+// An Attempt describes an error passed to a handler chain.
+type Attempt struct {
+	// Source is "must" for errors detected by Must, "defer" for errors
+	// returned by deferred functions, and "panic" for a panic caught by
+	// Handle.
+	Source string `json:"source"`
+
+	// Error is the error as it was detected.
+	Error string `json:"error"`
+
+	// Result is the error as returned by the handler chain, or by the
+	// PanicHandler for a panic. It is empty if the error was discarded.
+	Result string `json:"result,omitempty"`
+
+	// Discarded reports whether a handler discarded the error.
+	Discarded bool `json:"discarded"`
+
+	// Time is the time at which the error was detected.
+	Time time.Time `json:"time"`
+}
+
+// A DeferOutcome describes the result of running a deferred function.
+type DeferOutcome struct {
+	// Label is the label of the deferred function. See Label.
+	Label string `json:"label"`
+
+	// Error is the error returned by the deferred function, if any.
+	Error string `json:"error,omitempty"`
+
+	// Duration is the time it took to run the deferred function.
+	Duration time.Duration `json:"duration"`
+}
+
+func (r *Report) addAttempt(source Source, orig, err error, discarded bool) {
+	a := Attempt{
+		Source:    source.String(),
+		Error:     orig.Error(),
+		Discarded: discarded,
+		Time:      time.Now(),
+	}
+	if !discarded {
+		a.Result = err.Error()
+	}
+	r.Attempts = append(r.Attempts, a)
+}
+
+// A ReportSink receives the Reports of Catchers configured with ReportTo.
+type ReportSink interface {
+	// WriteReport writes r. It is called from Handle and must not retain r.
+	WriteReport(r *Report) error
+}
+
+// ReportTo returns an Option that causes a Report to be written to sink at the
+// end of Handle. Errors returned by sink are ignored.
+func ReportTo(sink ReportSink) Option {
+	return option(func(c *core) {
+		c.ext().report = &reporter{Report: Report{Start: time.Now()}, sink: sink}
+	})
+}
+
+type reporter struct {
+	Report
+	sink ReportSink
+}
+
+func runReported(e *Catcher, d deferData, label string) (recorded bool) {
+	if scopeOf(d) != nil {
+		// The deferred functions of the scope are reported individually.
+		if err := runDefer(e, d); err != nil {
+			return processDeferError(e, d, err)
+		}
+		return false
+	}
+	start := time.Now()
+	err := runDefer(e, d)
+	o := DeferOutcome{Label: label, Duration: time.Since(start)}
+	if err != nil {
+		o.Error = err.Error()
+	}
+	e.x.report.Defers = append(e.x.report.Defers, o)
+	return err != nil && processDeferError(e, d, err)
+}
+
+func writeReport(e *Catcher) {
+	r := &e.x.report.Report
+	r.Duration = time.Since(r.Start)
+	r.Panic = e.inPanic
+	r.Stats = e.stats
+	if err := (*state)(e).Err(); err != nil {
+		r.Error = err.Error()
+	}
+	e.x.report.sink.WriteReport(r)
+}
+
+// JSONReports returns a ReportSink that writes each Report to w as a single
+// line of JSON. It is safe for concurrent use.
+func JSONReports(w io.Writer) ReportSink {
+	return &jsonSink{enc: json.NewEncoder(w)}
+}
+
+type jsonSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (s *jsonSink) WriteReport(r *Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(r)
+}
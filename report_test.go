@@ -0,0 +1,133 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// reports records copies of the Reports it receives, as a ReportSink must not
+// retain them.
+type reports []*Report
+
+func (r *reports) WriteReport(x *Report) error {
+	c := *x
+	c.Attempts = append([]Attempt(nil), x.Attempts...)
+	c.Defers = append([]DeferOutcome(nil), x.Defers...)
+	*r = append(*r, &c)
+	return nil
+}
+
+func TestReport(t *testing.T) {
+	var got reports
+	errFoo := errors.New("foo")
+	errBar := errors.New("bar")
+	func() (err error) {
+		e := Catch(&err, ReportTo(&got), identity)
+		defer e.Handle()
+		e.Defer(func() error { return errBar }, Label("bar"))
+		e.Defer(func() {}, Label("void"))
+		e.Must(errFoo, Discard)
+		e.Must(errFoo)
+		return nil
+	}()
+	if len(got) != 1 {
+		t.Fatalf("got %d reports; want 1", len(got))
+	}
+	r := got[0]
+	if r.Error != "foo" || r.Panic {
+		t.Errorf("got error %q, panic %v; want %q, false", r.Error, r.Panic, "foo")
+	}
+	if len(r.Attempts) != 3 {
+		t.Fatalf("got %d attempts; want 3", len(r.Attempts))
+	}
+	for i, want := range []Attempt{
+		{Source: "must", Error: "foo", Discarded: true},
+		{Source: "must", Error: "foo", Result: "foo"},
+		{Source: "defer", Error: "bar", Result: "bar"},
+	} {
+		a := r.Attempts[i]
+		a.Time = want.Time
+		if a != want {
+			t.Errorf("%d: got %+v; want %+v", i, a, want)
+		}
+	}
+	if len(r.Defers) != 2 {
+		t.Fatalf("got %d defers; want 2", len(r.Defers))
+	}
+	if d := r.Defers[0]; d.Label != "void" || d.Error != "" {
+		t.Errorf("got %+v; want label void and no error", d)
+	}
+	if d := r.Defers[1]; d.Label != "bar" || d.Error != "bar" {
+		t.Errorf("got %+v; want label bar and error bar", d)
+	}
+}
+
+func TestReportPanic(t *testing.T) {
+	var got reports
+	func() {
+		defer func() { recover() }()
+		var err error
+		e := Catch(&err, ReportTo(&got))
+		defer e.Handle()
+		e.Defer(func() { panic("defer") })
+		panic("body")
+	}()
+	if len(got) != 1 {
+		t.Fatalf("got %d reports; want 1", len(got))
+	}
+	if r := got[0]; !r.Panic || r.Error != "errd: paniced: defer" {
+		t.Errorf("got panic %v, error %q", r.Panic, r.Error)
+	}
+	var sources []string
+	for _, a := range got[0].Attempts {
+		sources = append(sources, a.Source+": "+a.Error)
+	}
+	want := []string{"panic: errd: paniced: body", "panic: errd: paniced: defer"}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("got attempts %q; want %q", sources, want)
+	}
+}
+
+func TestReportRecover(t *testing.T) {
+	var got reports
+	func() (err error) {
+		e := Catch(&err, ReportTo(&got), HandlePanics(PanicHandlerFunc(
+			func(s State, p *PanicError) (error, bool) { return nil, false })))
+		defer e.Handle()
+		panic("body")
+	}()
+	if len(got) != 1 || len(got[0].Attempts) != 1 {
+		t.Fatalf("got %+v; want 1 report with 1 attempt", got)
+	}
+	a := got[0].Attempts[0]
+	a.Time = time.Time{}
+	want := Attempt{Source: "panic", Error: "errd: paniced: body", Discarded: true}
+	if a != want {
+		t.Errorf("got %+v; want %+v", a, want)
+	}
+}
+
+func TestJSONReports(t *testing.T) {
+	buf := &bytes.Buffer{}
+	func() (err error) {
+		e := Catch(&err, ReportTo(JSONReports(buf)))
+		defer e.Handle()
+		e.Must(errors.New("foo"))
+		return nil
+	}()
+	var r Report
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Error != "foo" || len(r.Attempts) != 1 {
+		t.Errorf("got %+v", r)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "time"
+
+// Retry returns a Handler that causes a failing deferred function to be called
+// again, up to n more times, before its error is passed to the remaining
+// handlers. It is meant for cleanup operations that may fail transiently, such
+// as closing a network-backed writer:
+//
+//     e.Defer(w.Close, errc.Retry(3, 100*time.Millisecond))
+//
+// The first retry waits for backoff, after which the wait is doubled for each
+// subsequent retry. Retrying stops early if the deadline of the context of the
+// Catcher would expire before the next attempt or if the context passed to
+// deferred functions, see ShutdownContext, is done. A cancelation of the
+// context of the Catcher does not stop retries, so that cleanup still runs
+// after the operation itself was canceled. Retry has no effect when passed to
+// Must and passes errors on unmodified.
+func Retry(n int, backoff time.Duration) Handler {
+	return &retry{n, backoff}
+}
+
+type retry struct {
+	n       int
+	backoff time.Duration
+}
+
+// Handle implements Handler.
+func (r *retry) Handle(s State, err error) error { return err }
+
+func (r *retry) run(s State, label string, f func(s State) error) error {
+	ctx := shutdownContext(s)
+	wait := r.backoff
+	err := f(s)
+	for i := 0; err != nil && i < r.n; i++ {
+		if d, ok := s.Context().Deadline(); ok && time.Until(d) < wait {
+			break
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		wait *= 2
+		err = f(s)
+	}
+	return err
+}
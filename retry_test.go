@@ -0,0 +1,128 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	errFail := errors.New("fail")
+	testCases := []struct {
+		desc     string
+		n        int
+		failures int
+		want     error
+		calls    int
+	}{
+		{"success", 3, 0, nil, 1},
+		{"transient", 3, 2, nil, 3},
+		{"permanent", 2, 5, errFail, 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			calls := 0
+			err := Run(func(e *Catcher) error {
+				e.Defer(func() error {
+					calls++
+					if calls <= tc.failures {
+						return errFail
+					}
+					return nil
+				}, Retry(tc.n, time.Microsecond))
+				return nil
+			})
+			if err != tc.want {
+				t.Errorf("got %v; want %v", err, tc.want)
+			}
+			if calls != tc.calls {
+				t.Errorf("got %d calls; want %d", calls, tc.calls)
+			}
+		})
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	calls := 0
+	start := time.Now()
+	err := RunWithContext(ctx, func(ctx context.Context, e *Catcher) error {
+		e.Defer(func() error {
+			calls++
+			return errors.New("fail")
+		}, Retry(3, 2*time.Hour))
+		return nil
+	})
+	if err == nil {
+		t.Error("got nil; want error")
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls; want 1", calls)
+	}
+	if time.Since(start) > time.Minute {
+		t.Error("Retry waited beyond the deadline")
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := RunWithContext(ctx, func(ctx context.Context, e *Catcher) error {
+		e.Defer(func() error {
+			calls++
+			return errors.New("fail")
+		}, Retry(2, time.Microsecond))
+		cancel()
+		return nil
+	})
+	if err == nil {
+		t.Error("got nil; want error")
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls; want 3", calls)
+	}
+}
+
+func TestRetryShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Run(func(e *Catcher) error {
+		e.Defer(func() error {
+			calls++
+			cancel()
+			return errors.New("fail")
+		}, Retry(2, time.Hour))
+		return nil
+	}, ShutdownContext(ctx))
+	if err == nil {
+		t.Error("got nil; want error")
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls; want 1", calls)
+	}
+}
+
+func TestRetryHandlers(t *testing.T) {
+	var got error
+	err := Run(func(e *Catcher) error {
+		e.Defer(func() error {
+			return errors.New("fail")
+		}, Retry(1, time.Microsecond), Wrap("close"), HandlerFunc(func(s State, err error) error {
+			got = err
+			return err
+		}))
+		return nil
+	})
+	if err == nil || err.Error() != "close: fail" {
+		t.Errorf("got %v; want close: fail", err)
+	}
+	if got != err {
+		t.Errorf("handler got %v; want %v", got, err)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "context"
+
+// Run calls f with a new Catcher created with the given handlers and options,
+// and returns the resulting error. Run takes care of calling Handle, so that
+//
+//     err := errc.Run(func(e *errc.Catcher) error {
+//         r, err := getReader()
+//         e.Must(err)
+//         e.Defer(r.Close)
+//         return process(r)
+//     })
+//
+// is equivalent to
+//
+//     err := func() (err error) {
+//         e := errc.Catch(&err)
+//         defer e.Handle()
+//
+//         r, err := getReader()
+//         e.Must(err)
+//         e.Defer(r.Close)
+//         return process(r)
+//     }()
+//
+// A panic in f is passed on to the caller of Run after the deferred functions
+// have run.
+func Run(f func(e *Catcher) error, h ...Handler) (err error) {
+	e := Catch(&err, h...)
+	defer e.Handle()
+	return f(&e)
+}
+
+// RunWithContext is like Run, but creates the Catcher with CatchContext and
+// passes the context of the Catcher to f.
+func RunWithContext(ctx context.Context, f func(ctx context.Context, e *Catcher) error, h ...Handler) (err error) {
+	e := CatchContext(ctx, &err, h...)
+	defer e.Handle()
+	return f(e.Context(), &e)
+}
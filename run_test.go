@@ -0,0 +1,80 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	errFoo := errors.New("foo")
+	testCases := []struct {
+		desc string
+		f    func(e *Catcher) error
+		want error
+	}{{
+		desc: "success",
+		f:    func(e *Catcher) error { return nil },
+	}, {
+		desc: "return",
+		f:    func(e *Catcher) error { return errFoo },
+		want: errFoo,
+	}, {
+		desc: "must",
+		f: func(e *Catcher) error {
+			e.Must(errFoo)
+			return nil
+		},
+		want: errFoo,
+	}, {
+		desc: "defer",
+		f: func(e *Catcher) error {
+			e.Defer(func() error { return errFoo })
+			return nil
+		},
+		want: errFoo,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := Run(tc.f); got != tc.want {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunPanic(t *testing.T) {
+	deferred := false
+	defer func() {
+		if r := recover(); r != "foo" {
+			t.Errorf("got panic %v; want foo", r)
+		}
+		if !deferred {
+			t.Error("deferred function not run")
+		}
+	}()
+	Run(func(e *Catcher) error {
+		e.Defer(func() { deferred = true })
+		panic("foo")
+	})
+}
+
+func TestRunWithContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "ctx")
+	err := RunWithContext(ctx, func(ctx context.Context, e *Catcher) error {
+		e.Must(errors.New("foo"), HandlerFunc(func(s State, err error) error {
+			if s.Context() != ctx {
+				t.Error("State.Context does not return the context of RunWithContext")
+			}
+			return nil
+		}))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("got %v; want nil", err)
+	}
+}
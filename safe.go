@@ -0,0 +1,161 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"sync"
+)
+
+// CatchSafe is like Catch, but returns a Catcher whose Must and Defer may also
+// be called from goroutines other than the one that called CatchSafe, such as
+// the completion callbacks of an asynchronous API:
+//
+//     e := errc.CatchSafe(&err)
+//     defer e.Handle()
+//
+//     var wg sync.WaitGroup
+//     for _, name := range names {
+//         wg.Add(1)
+//         client.OpenAsync(name, func(f *File, err error) {
+//             defer wg.Done()
+//             e.Must(err, errc.Wrapf("open %s", name))
+//             e.Defer(f.Close)
+//         })
+//     }
+//     wg.Wait()
+//     e.Must(nil) // return if any of the callbacks failed
+//
+// Calls from other goroutines return immediately, even if an error is detected.
+// The error and deferred functions are queued and picked up by the owning
+// goroutine at its next call to Must or Defer or while Handle runs. This means
+// that all handlers and deferred functions run on the owning goroutine and
+// that deferred functions are run in reverse order of being picked up. A call
+// to Must on the owning goroutine causes a return from the function if any of
+// the queued errors is recorded, even if the error passed to it is nil. As
+// with Catch, the first recorded error wins.
+//
+// Must and Defer panic if they are called from other goroutines after Handle
+// completed. Failed, Scope, and all other methods may only be used by the
+// owner.
+//
+// The calling goroutine is identified from its stack trace, which makes each
+// call to Must and Defer allocate and cost in the order of microseconds, many
+// times as much as for a Catcher returned by Catch. CatchSafe should therefore
+// not be used in hot paths.
+func CatchSafe(err *error, h ...Handler) Catcher {
+	ec := Catch(err, h...)
+	ec.safe = true
+	ec.ext().safe = &safeState{owner: goid()}
+	return ec
+}
+
+var errSafeHandled = errors.New("errd: use of Catcher after Handle")
+
+// safeState holds the calls to a Catcher created with CatchSafe that were made
+// from goroutines other than its owner.
+type safeState struct {
+	mu     sync.Mutex
+	owner  int64 // goroutine id of the owner
+	closed bool  // no more calls are accepted
+	defers []deferData
+	errs   []queuedError
+}
+
+type queuedError struct {
+	err      error
+	handlers []Handler
+	pc       uintptr
+}
+
+// mustSafe queues err if Must is called from a goroutine other than the owner
+// or picks up queued calls otherwise. It reports whether Must should return.
+func mustSafe(e *Catcher, err error, h []Handler) (done bool) {
+	s := e.x.safe
+	if goid() == s.owner {
+		if !mergeSafe(e, true) {
+			return false
+		}
+		switch {
+		case e.collect:
+			return false
+		case e.lite:
+			failLite(e)
+			return true
+		}
+		bail(e)
+	}
+	if err == nil && e.ctx != nil {
+		err = e.ctx.Err()
+	}
+	var pc uintptr
+	if err != nil && e.recordCallers {
+		pc = callerPC()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		panic(errSafeHandled)
+	}
+	if err != nil {
+		s.errs = append(s.errs, queuedError{err, append([]Handler(nil), h...), pc})
+	}
+	return true
+}
+
+// deferSafe queues a deferred function if Defer is called from a goroutine
+// other than the owner or picks up queued functions otherwise. It reports
+// whether the function was queued.
+func deferSafe(e *Catcher, x interface{}, f deferFunc, h []Handler) (queued bool) {
+	s := e.x.safe
+	if goid() == s.owner {
+		mergeSafe(e, false)
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		panic(errSafeHandled)
+	}
+	s.defers = appendHandlers(s.defers, h)
+	s.defers = append(s.defers, deferData{x: x, f: f})
+	return true
+}
+
+// mergeSafe moves the queued deferred functions to the defer stack of e and, if
+// errs is set, processes the queued errors. It must be called by the owner. It
+// reports whether any of the errors was recorded.
+func mergeSafe(e *Catcher, errs bool) (recorded bool) {
+	s := e.x.safe
+	s.mu.Lock()
+	defers := s.defers
+	s.defers = nil
+	var queued []queuedError
+	if errs {
+		queued, s.errs = s.errs, nil
+	}
+	s.mu.Unlock()
+	e.deferred = append(e.deferred, defers...)
+	for _, q := range queued {
+		e.stats.Musts++
+		if checkError(e, q.err, q.handlers, q.pc) {
+			recorded = true
+		}
+	}
+	return recorded
+}
+
+// closeSafe stops e from accepting calls from other goroutines. It reports
+// false, without closing e, if there are still queued calls.
+func closeSafe(e *Catcher) bool {
+	s := e.x.safe
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.defers) > 0 || len(s.errs) > 0 {
+		return false
+	}
+	s.closed = true
+	return true
+}
@@ -0,0 +1,172 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCatchSafe(t *testing.T) {
+	errFail := errors.New("fail")
+	var got []string
+	reached := false
+	err := func() (err error) {
+		e := CatchSafe(&err)
+		defer e.Handle()
+		e.Defer(func() { got = append(got, "owner") })
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				e.Defer(func() { got = append(got, "callback") })
+				if i == 3 {
+					e.Must(errFail, Wrap("callback"))
+				}
+			}(i)
+		}
+		wg.Wait()
+		e.Must(nil)
+		reached = true
+		return nil
+	}()
+	if reached {
+		t.Error("Must did not return after a failed callback")
+	}
+	if !errors.Is(err, errFail) || err.Error() != "callback: fail" {
+		t.Errorf("got %v; want callback: fail", err)
+	}
+	if len(got) != 11 || got[10] != "owner" {
+		t.Errorf("got %q; want 10 callbacks followed by owner", got)
+	}
+}
+
+func TestCatchSafeDuringHandle(t *testing.T) {
+	var got []string
+	err := func() (err error) {
+		e := CatchSafe(&err)
+		defer e.Handle()
+		e.Defer(func() { got = append(got, "first") })
+		e.Defer(func() {
+			// Register a deferred function from another goroutine while
+			// Handle runs the deferred functions.
+			done := make(chan bool, 1)
+			go func() {
+				e.Defer(func() { got = append(got, "callback") })
+				e.Must(errors.New("late"))
+				done <- true
+			}()
+			<-done
+		})
+		return nil
+	}()
+	if err == nil || err.Error() != "late" {
+		t.Errorf("got %v; want late", err)
+	}
+	if want := []string{"callback", "first"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestCatchSafeOwner(t *testing.T) {
+	var got []string
+	err := func() (err error) {
+		e := CatchSafe(&err)
+		defer e.Handle()
+		e.Defer(func() { got = append(got, "a") })
+		e.Defer(func() { got = append(got, "b") })
+		e.Must(nil)
+		return nil
+	}()
+	if err != nil {
+		t.Errorf("got %v; want nil", err)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestCatchSafeLite(t *testing.T) {
+	var got []string
+	err := func() (err error) {
+		e := CatchSafe(&err)
+		e.lite = true
+		defer e.Handle()
+		e.Defer(func() { got = append(got, "deferred") })
+		done := make(chan bool, 1)
+		go func() {
+			e.Must(errors.New("callback"))
+			done <- true
+		}()
+		<-done
+		e.Must(nil)
+		if !e.Failed() {
+			t.Error("Failed: got false; want true")
+		}
+		got = append(got, "returned")
+		return nil
+	}()
+	if err == nil || err.Error() != "callback" {
+		t.Errorf("got %v; want callback", err)
+	}
+	if want := []string{"deferred", "returned"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestCatchSafeAfterHandle(t *testing.T) {
+	var e Catcher
+	func() {
+		var err error
+		e = CatchSafe(&err)
+		defer e.Handle()
+	}()
+	done := make(chan interface{})
+	go func() {
+		defer func() { done <- recover() }()
+		e.Must(errors.New("late"))
+	}()
+	if r := <-done; r != errSafeHandled {
+		t.Errorf("got %v; want %v", r, errSafeHandled)
+	}
+}
+
+// BenchmarkSafe measures the cost of the goroutine check that CatchSafe adds to
+// Must and Defer.
+func BenchmarkSafe(b *testing.B) {
+	bc := []struct {
+		name  string
+		catch func(err *error, h ...Handler) Catcher
+	}{
+		{"catch", Catch},
+		{"safe", CatchSafe},
+	}
+	for _, bc := range bc {
+		b.Run(bc.name+"/must", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				func() (err error) {
+					e := bc.catch(&err)
+					defer e.Handle()
+					e.Must(nil)
+					return nil
+				}()
+			}
+		})
+		b.Run(bc.name+"/defer", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				func() (err error) {
+					e := bc.catch(&err)
+					defer e.Handle()
+					e.Defer(func() error { return nil })
+					return nil
+				}()
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+// Scope returns a child Catcher of e. The deferred functions of the scope can
+// be run before the function returns by calling Flush, which is useful for
+// releasing the resources acquired in each iteration of a loop:
+//
+//     for _, name := range files {
+//         s := e.Scope()
+//         f, err := os.Open(name)
+//         s.Must(err)
+//         s.Defer(f.Close)
+//         process(s, f)
+//         s.Flush()
+//     }
+//
+// A scope shares the error variable, handlers, and options of e. Errors detected
+// by the scope are recorded as if they were detected by e; in particular, Must
+// causes a return from the function that called Catch. If e is a lite Catcher,
+// a failure of the scope marks e as failed and runs the deferred functions of
+// e. The deferred functions of a scope that are not flushed are run by the
+// Handle method of e.
+//
+// A scope must not be passed to Handle. The statistics of a scope are added to
+// those of e when it is flushed.
+func (e *Catcher) Scope() *Catcher {
+	s := &Catcher{e.core}
+	s.deferred = s.buf[:0]
+	s.stats = Stats{}
+	s.failed = false
+	s.parent = e
+	s.registered = false
+	s.safe = false // scopes are not shared with other goroutines
+	return s
+}
+
+// Flush runs all deferred functions registered with e so far, in the usual
+// reverse order. If any of them returns an error that is not discarded by its
+// handlers, Flush causes a return from the function, just as Must does.
+func (e *Catcher) Flush() {
+	failed := doDefers(e, 0)
+	if failed {
+		e.failed = true
+	}
+	if e.parent != nil {
+		unregister(e)
+	}
+	switch {
+	case !failed, e.collect:
+	case e.lite:
+		failLite(e)
+	default:
+		panic(errOurPanic)
+	}
+}
+
+// failLite marks the Catcher that called Catch as failed and runs its deferred
+// functions, including those of its scopes, after the lite Catcher e or one of
+// its scopes detected an error.
+func failLite(e *Catcher) {
+	for e.parent != nil {
+		e.failed = true
+		e = e.parent
+	}
+	e.failed = true
+	doDefers(e, 0)
+}
+
+// owner returns the core of the outermost Catcher of which e is a scope, or e
+// itself if it is not a scope.
+func (e *core) owner() *core {
+	for e.parent != nil {
+		e = &e.parent.core
+	}
+	return e
+}
+
+// register ensures the deferred functions of scope s are run by its parent.
+// Registration is deferred until the first call to Defer, so that scopes
+// without deferred functions have no cost to the parent. A parent that is
+// itself an unregistered scope is registered first, so that the entry of s is
+// reached from the Catcher that called Catch.
+func register(s *Catcher) {
+	p := s.parent
+	if p.parent != nil && !p.registered {
+		register(p)
+	}
+	p.deferred = append(p.deferred, deferData{x: s, f: flushScope})
+	s.registered = true
+}
+
+// unregister merges the state of scope s into its parent after it has been
+// flushed. The scope's entry in the parent is removed if it is the most
+// recently registered one, which is typical for loops, so that the parent does
+// not grow with each iteration.
+func unregister(s *Catcher) {
+	p := s.parent
+	p.stats.add(s.stats)
+	s.stats = Stats{}
+	p.failed = p.failed || s.failed
+	if n := len(p.deferred) - 1; s.registered && n >= 0 && p.deferred[n].x == interface{}(s) {
+		p.deferred = p.deferred[:n]
+		s.registered = false
+	}
+}
+
+// scopeOf returns the scope of which d is the entry in its parent, or nil if
+// d is not such an entry.
+func scopeOf(d deferData) *Catcher {
+	if s, ok := d.x.(*Catcher); ok && d.f != nil && s.parent != nil {
+		return s
+	}
+	return nil
+}
+
+func flushScope(st State, x interface{}) error {
+	s := x.(*Catcher)
+	s.inPanic = st.Panicking()
+	doDefers(s, 0)
+	unregister(s)
+	s.registered = false
+	// The entry of the scope was counted as a deferred function.
+	s.parent.stats.DefersRun--
+	return nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	var result string
+	var err error
+	func() {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Defer(func() { result += ":parent" })
+		for _, s := range []string{"a", "b", "c"} {
+			sc := e.Scope()
+			sc.Defer(func() { result += ":" + s })
+			sc.Flush()
+			if n := len(e.deferred); n != 1 {
+				t.Errorf("%s: got %d deferred functions in parent; want 1", s, n)
+			}
+		}
+		result += ":done"
+	}()
+	if err != nil {
+		t.Errorf("got %v; want nil", err)
+	}
+	if want := ":a:b:c:done:parent"; result != want {
+		t.Errorf("got %q; want %q", result, want)
+	}
+}
+
+func TestScopeUnflushed(t *testing.T) {
+	var result string
+	var err error
+	func() {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Defer(func() { result += ":parent1" })
+		sc := e.Scope()
+		sc.Defer(func() { result += ":scope" })
+		e.Defer(func() { result += ":parent2" })
+	}()
+	if want := ":parent2:scope:parent1"; result != want {
+		t.Errorf("got %q; want %q", result, want)
+	}
+}
+
+func TestScopeNested(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		flush bool
+		want  string
+	}{
+		{"handle", false, ":done:inner:parent"},
+		{"flush", true, ":inner:done:parent"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var result string
+			var err error
+			func() {
+				e := Catch(&err)
+				defer e.Handle()
+				e.Defer(func() { result += ":parent" })
+				s1 := e.Scope()
+				s2 := s1.Scope()
+				s2.Defer(func() { result += ":inner" })
+				if tc.flush {
+					s1.Flush()
+				}
+				result += ":done"
+			}()
+			if result != tc.want {
+				t.Errorf("got %q; want %q", result, tc.want)
+			}
+		})
+	}
+}
+
+func TestScopeMust(t *testing.T) {
+	var result string
+	var err error
+	var stats Stats
+	func() {
+		e := Catch(&err)
+		defer func() { stats = e.Stats() }()
+		defer e.Handle()
+		e.Defer(func() { result += ":parent" })
+		sc := e.Scope()
+		sc.Defer(func() { result += ":scope" })
+		sc.Must(errors.New("fail"))
+		result += ":unreachable"
+	}()
+	if err == nil || err.Error() != "fail" {
+		t.Errorf("got %v; want fail", err)
+	}
+	if want := ":scope:parent"; result != want {
+		t.Errorf("got %q; want %q", result, want)
+	}
+	if stats.Musts != 1 || stats.DefersRun != 2 {
+		t.Errorf("got %+v; want 1 Must and 2 defers run", stats)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	var result string
+	var err error
+	func() {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Defer(func() error {
+			result += ":first"
+			return errors.New("fail")
+		})
+		e.Defer(func() { result += ":second" })
+		e.Flush()
+		result += ":unreachable"
+	}()
+	if err == nil || err.Error() != "fail" {
+		t.Errorf("got %v; want fail", err)
+	}
+	if want := ":second:first"; result != want {
+		t.Errorf("got %q; want %q", result, want)
+	}
+}
+
+func TestFlushDiscarded(t *testing.T) {
+	var result string
+	var err error
+	func() {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Defer(func() error { return errors.New("fail") }, Discard)
+		e.Flush()
+		result += ":continued"
+	}()
+	if err != nil {
+		t.Errorf("got %v; want nil", err)
+	}
+	if result != ":continued" {
+		t.Errorf("got %q; want %q", result, ":continued")
+	}
+}
+
+func TestScopeLite(t *testing.T) {
+	errFail := errors.New("fail")
+	for _, flush := range []bool{false, true} {
+		var ran []string
+		parentFailed := false
+		err := func() (err error) {
+			e := CatchLite(&err)
+			defer e.Handle()
+			e.Defer(func() { ran = append(ran, "parent") })
+			s := e.Scope()
+			s.Defer(func() { ran = append(ran, "scope") })
+			if flush {
+				s.Defer(func() error { return errFail })
+				s.Flush()
+			} else {
+				s.Must(errFail)
+			}
+			if parentFailed = e.Failed(); parentFailed {
+				return nil
+			}
+			ran = append(ran, "continued")
+			return nil
+		}()
+		if err != errFail {
+			t.Errorf("flush=%v: got %v; want %v", flush, err, errFail)
+		}
+		if !parentFailed {
+			t.Errorf("flush=%v: parent not marked as failed", flush)
+		}
+		if want := "scope parent"; strings.Join(ran, " ") != want {
+			t.Errorf("flush=%v: got %q; want %q", flush, ran, want)
+		}
+	}
+}
+
+func TestScopeEntry(t *testing.T) {
+	var got []string
+	index := -1
+	sink := &reports{}
+	func() {
+		var err error
+		e := Catch(&err, ReportTo(sink))
+		defer e.Handle()
+		e.Defer(func() {}, Label("parent"))
+		s := e.Scope()
+		s.Defer(func() {}, Label("child"))
+		e.Defer(func() error {
+			return errors.New("fail")
+		}, Label("last"), HandlerFunc(func(s State, err error) error {
+			index, _ = s.Deferred()
+			return nil
+		}))
+		e.Defer(func(s State) error {
+			got = s.Pending()
+			return nil
+		})
+	}()
+	if want := []string{"last", "child", "parent"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("pending: got %q; want %q", got, want)
+	}
+	if index != 2 {
+		t.Errorf("index: got %d; want 2", index)
+	}
+	var labels []string
+	for _, d := range (*sink)[0].Defers {
+		labels = append(labels, d.Label)
+	}
+	if n := len(labels); n != 4 || labels[n-2] != "child" || labels[n-1] != "parent" {
+		t.Errorf("reported defers: got %q", labels)
+	}
+}
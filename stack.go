@@ -0,0 +1,144 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// StackTrace is a Handler that annotates errors with the stack trace of the
+// point at which they were detected. The trace can be retrieved with Stack.
+// Frames of package errc itself are omitted, so that the trace starts at user
+// code.
+var StackTrace Handler = HandlerFunc(stackTrace)
+
+func stackTrace(s State, err error) error {
+	return &stackError{err, s.Stack()}
+}
+
+type stackError struct {
+	err   error
+	stack []runtime.Frame
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+func (e *stackError) Unwrap() error { return e.err }
+
+// Stack returns the stack trace attached to err, or any of the errors it
+// wraps, by StackTrace or a *PanicError. It returns nil if there is none.
+func Stack(err error) []runtime.Frame {
+	var stack []runtime.Frame
+	walk(err, func(err error) {
+		if stack != nil {
+			return
+		}
+		switch x := err.(type) {
+		case *stackError:
+			stack = x.stack
+		case *PanicError:
+			stack = x.Stack
+		}
+	})
+	return stack
+}
+
+// CallerSkip returns an Option that causes n additional frames to be omitted
+// from the start of captured stack traces. It allows libraries that wrap
+// package errc to hide their own frames.
+func CallerSkip(n int) Option {
+	return option(func(c *core) { c.ext().callerSkip += n })
+}
+
+// RecordCallers is an Option that causes the locations of calls to Must and
+// Defer to be recorded, so that handlers can report them through
+// State.Caller. Recording callers incurs some overhead for each call to Defer
+// and for each error detected by Must.
+var RecordCallers Option = option(func(c *core) { c.recordCallers = true })
+
+// callerPC returns the program counter of the first caller outside package
+// errc.
+func callerPC() uintptr {
+	var pcs [8]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for more := true; more; {
+		var f runtime.Frame
+		f, more = frames.Next()
+		if !isInternal(f) {
+			return f.PC
+		}
+	}
+	return 0
+}
+
+// frameAt returns the frame for a program counter returned by callerPC.
+func frameAt(pc uintptr) runtime.Frame {
+	if pc == 0 {
+		return runtime.Frame{}
+	}
+	// Frame.PC is the address of the call instruction rather than a return
+	// address as expected by CallersFrames.
+	f, _ := runtime.CallersFrames([]uintptr{pc + 1}).Next()
+	return f
+}
+
+const maxDepth = 64
+
+// callers returns the stack of the calling goroutine, stripped of the frames
+// of package errc and the runtime frames that precede the first user frame.
+func callers(s State) []runtime.Frame {
+	skip := 0
+	if st, ok := s.(*state); ok {
+		skip = st.extOrZero().callerSkip
+	}
+	var pcs [maxDepth]uintptr
+	n := runtime.Callers(1, pcs[:])
+	return trimFrames(pcs[:n], skip)
+}
+
+func trimFrames(pcs []uintptr, skip int) []runtime.Frame {
+	var all []runtime.Frame
+	frames := runtime.CallersFrames(pcs)
+	for more, found := true, false; more; {
+		var f runtime.Frame
+		f, more = frames.Next()
+		if f.Function == handleFunc && !found {
+			// Drop the frames of the handler asking for the stack.
+			all, found = all[:0], true
+		}
+		all = append(all, f)
+	}
+	var stack []runtime.Frame
+	for _, f := range all {
+		switch {
+		case isInternal(f):
+		case len(stack) == 0 && strings.HasPrefix(f.Function, "runtime."):
+		case skip > 0:
+			skip--
+		default:
+			stack = append(stack, f)
+		}
+	}
+	return stack
+}
+
+// handleFunc is the name of the function through which all handlers are
+// called.
+var handleFunc = runtime.FuncForPC(reflect.ValueOf(errorHandler.handle).Pointer()).Name()
+
+// pkgDir is the directory holding the source of package errc.
+var pkgDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// isInternal reports whether f is a frame of package errc, excluding its
+// tests.
+func isInternal(f runtime.Frame) bool {
+	return filepath.Dir(f.File) == pkgDir && !strings.HasSuffix(f.File, "_test.go")
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func failWithStack(h ...Handler) (err error) {
+	e := Catch(&err, h...)
+	defer e.Handle()
+	e.Must(errors.New("foo"), StackTrace)
+	return nil
+}
+
+func wrapper() error { return failWithStack(CallerSkip(1)) }
+
+func TestStackTrace(t *testing.T) {
+	testCases := []struct {
+		f    func() error
+		want string
+	}{{
+		f:    func() error { return failWithStack() },
+		want: "failWithStack",
+	}, {
+		f:    wrapper,
+		want: "wrapper",
+	}, {
+		f: func() (err error) {
+			e := Catch(&err)
+			defer e.Handle()
+			e.Defer(func() error { return errors.New("foo") }, StackTrace)
+			return nil
+		},
+		want: "TestStackTrace.func",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			err := tc.f()
+			stack := Stack(fmt.Errorf("wrapped: %w", err))
+			if len(stack) == 0 {
+				t.Fatal("no stack trace")
+			}
+			if !strings.Contains(stack[0].Function, tc.want) {
+				t.Errorf("got %s; want frame containing %q", stack[0].Function, tc.want)
+			}
+			for _, f := range stack {
+				if isInternal(f) {
+					t.Errorf("stack contains internal frame %s", f.Function)
+				}
+			}
+		})
+	}
+}
+
+func panicker() { panic("foo") }
+
+func TestPanicStack(t *testing.T) {
+	var err error
+	func() {
+		defer func() { recover() }()
+		e := Catch(&err)
+		defer e.Handle()
+		panicker()
+	}()
+	var p *PanicError
+	if !errors.As(err, &p) {
+		t.Fatalf("got %T; want *PanicError", err)
+	}
+	if p.Value != "foo" {
+		t.Errorf("Value: got %v; want foo", p.Value)
+	}
+	stack := Stack(err)
+	if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, ".panicker") {
+		t.Errorf("got stack %v; want stack starting at panicker", stack)
+	}
+}
+
+func TestStateStack(t *testing.T) {
+	var stack []runtime.Frame
+	func() (err error) {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Must(errors.New("foo"), HandlerFunc(func(s State, err error) error {
+			stack = s.Stack()
+			return err
+		}))
+		return nil
+	}()
+	if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, "TestStateStack.func1") {
+		t.Errorf("got stack %v; want stack starting at TestStateStack", stack)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	var lines []int
+	h := HandlerFunc(func(s State, err error) error {
+		lines = append(lines, s.Caller().Line)
+		return nil
+	})
+	_, _, line, _ := runtime.Caller(0)
+	func() (err error) {
+		e := Catch(&err, RecordCallers)
+		defer e.Handle()
+		e.Defer(func() error { return errors.New("defer") }, h) // line+4
+		e.Must(errors.New("must"), h)                           // line+5
+		e.Helper().Must(errors.New("helper"), h)                // line+6
+		return nil
+	}()
+	want := []int{line + 5, line + 6, line + 4}
+	if fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("got lines %v; want %v", lines, want)
+	}
+
+	func() (err error) {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Must(errors.New("must"), HandlerFunc(func(s State, err error) error {
+			if f := s.Caller(); f != (runtime.Frame{}) {
+				t.Errorf("got %v; want zero Frame", f)
+			}
+			return nil
+		}))
+		return nil
+	}()
+}
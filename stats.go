@@ -0,0 +1,38 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+// Stats holds counts of the error handling activity of a Catcher.
+type Stats struct {
+	Musts            int `json:"musts"`             // calls to Must
+	Errors           int `json:"errors"`            // errors passed to a handler chain
+	Discarded        int `json:"discarded"`         // errors discarded by a handler
+	DefersRegistered int `json:"defers_registered"` // functions registered with Defer
+	DefersRun        int `json:"defers_run"`        // deferred functions run
+	HandlerCalls     int `json:"handler_calls"`     // invocations of handlers
+}
+
+func (s *Stats) count(discarded bool) {
+	s.Errors++
+	if discarded {
+		s.Discarded++
+	}
+}
+
+func (s *Stats) add(t Stats) {
+	s.Musts += t.Musts
+	s.Errors += t.Errors
+	s.Discarded += t.Discarded
+	s.DefersRegistered += t.DefersRegistered
+	s.DefersRun += t.DefersRun
+	s.HandlerCalls += t.HandlerCalls
+}
+
+// Stats reports the error handling statistics of e. It is typically called
+// after Handle to report a summary for long-running jobs. Handlers and
+// Observers can obtain the statistics gathered so far with State.Stats.
+func (e *Catcher) Stats() Stats {
+	return e.stats
+}
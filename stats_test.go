@@ -0,0 +1,69 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	var e Catcher
+	func() (err error) {
+		e = Catch(&err, identity)
+		defer e.Handle()
+		e.Defer(func() error { return errors.New("defer") })
+		e.Defer(func() {})
+		e.Must(nil)
+		e.Must(errors.New("discard"), identity, Discard)
+		e.Must(errors.New("fail"))
+		return nil
+	}()
+	want := Stats{
+		Musts:            3,
+		Errors:           3,
+		Discarded:        1,
+		DefersRegistered: 2,
+		DefersRun:        2,
+		HandlerCalls:     4,
+	}
+	if got := e.Stats(); got != want {
+		t.Errorf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestStateStats(t *testing.T) {
+	var got []Stats
+	record := HandlerFunc(func(s State, err error) error {
+		got = append(got, s.Stats())
+		return err
+	})
+	func() (err error) {
+		e := Catch(&err)
+		defer e.Handle()
+		e.Must(nil)
+		e.Defer(func() error { return errors.New("defer") }, record)
+		s := e.Scope()
+		s.Must(errors.New("scope"), record, Discard)
+		s.Flush()
+		return nil
+	}()
+	want := []Stats{{
+		Musts:            2,
+		DefersRegistered: 1,
+		HandlerCalls:     1,
+	}, {
+		Musts:            2,
+		Errors:           1,
+		Discarded:        1,
+		DefersRegistered: 1,
+		DefersRun:        1,
+		HandlerCalls:     3,
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v; want %+v", got, want)
+	}
+}
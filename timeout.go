@@ -0,0 +1,136 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// A TimeoutError is reported for a deferred function that did not complete
+// within the time allotted by Timeout or DeferTimeout.
+type TimeoutError struct {
+	// Label is the label of the deferred function. See Label.
+	Label string
+
+	// Timeout is the time allotted to the deferred function.
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("errd: deferred %s did not complete within %v", e.Label, e.Timeout)
+}
+
+// Timeout returns a Handler that bounds the time a deferred function may take
+// to d. If the function does not complete in time, a *TimeoutError is passed to
+// the remaining handlers instead of the result of the function. This protects
+// against cleanup calls that hang, such as closing a file on an unresponsive
+// network file system:
+//
+//     e.Defer(f.Close, errc.Timeout(5*time.Second))
+//
+// The deferred function is run in a separate goroutine, which is abandoned if
+// the function does not complete in time. The function is passed a snapshot of
+// the State taken when it is started, so that an abandoned function does not
+// interfere with the Catcher. A panic in the function is resumed in the
+// goroutine running the defers. Timeout has no effect when passed to
+// Must and passes errors on unmodified.
+func Timeout(d time.Duration) Handler {
+	return timeout(d)
+}
+
+type timeout time.Duration
+
+// Handle implements Handler.
+func (t timeout) Handle(s State, err error) error { return err }
+
+type result struct {
+	err   error
+	panic interface{}
+}
+
+func (t timeout) run(s State, label string, f func(s State) error) error {
+	snap := freeze(s)
+	c := make(chan result, 1)
+	go func() {
+		var r result
+		defer func() {
+			if p := recover(); p != nil {
+				r.panic = p
+			}
+			c <- r
+		}()
+		r.err = f(snap)
+	}()
+	timer := time.NewTimer(time.Duration(t))
+	defer timer.Stop()
+	select {
+	case r := <-c:
+		if r.panic != nil {
+			panic(r.panic)
+		}
+		return r.err
+	case <-timer.C:
+		return &TimeoutError{Label: label, Timeout: time.Duration(t)}
+	}
+}
+
+// DeferTimeout is like Defer, but bounds the time the deferred function may
+// take to d, as if Timeout(d) were passed as the first of the handlers.
+func (e *Catcher) DeferTimeout(d time.Duration, x interface{}, h ...Handler) {
+	e.Defer(x, append([]Handler{Timeout(d)}, h...)...)
+}
+
+// A snapshot is a State that is not affected by subsequent changes to the
+// Catcher from which it was taken.
+type snapshot struct {
+	panicking   bool
+	failed      bool
+	err         error
+	pending     []string
+	ctx         context.Context
+	shutdownCtx context.Context
+	stack       []runtime.Frame
+	caller      runtime.Frame
+	source      Source
+	index       int
+	label       string
+	stats       Stats
+}
+
+// freeze returns a snapshot of s if it is the State of a Catcher, or s itself
+// otherwise.
+func freeze(s State) State {
+	st, ok := s.(*state)
+	if !ok {
+		return s
+	}
+	snap := &snapshot{
+		panicking:   st.inPanic,
+		failed:      st.failed,
+		err:         st.Err(),
+		pending:     st.Pending(),
+		ctx:         st.Context(),
+		shutdownCtx: st.extOrZero().shutdownCtx,
+		stack:       st.Stack(),
+		caller:      st.Caller(),
+		source:      st.Source(),
+		stats:       st.Stats(),
+	}
+	snap.index, snap.label = st.Deferred()
+	return snap
+}
+
+func (s *snapshot) Panicking() bool                     { return s.panicking }
+func (s *snapshot) Err() error                          { return s.err }
+func (s *snapshot) Pending() []string                   { return s.pending }
+func (s *snapshot) Context() context.Context            { return s.ctx }
+func (s *snapshot) Stack() []runtime.Frame              { return s.stack }
+func (s *snapshot) Caller() runtime.Frame               { return s.caller }
+func (s *snapshot) Source() Source                      { return s.source }
+func (s *snapshot) Deferred() (index int, label string) { return s.index, s.label }
+func (s *snapshot) Stats() Stats                        { return s.stats }
@@ -0,0 +1,82 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeferTimeout(t *testing.T) {
+	hang := make(chan struct{}, 1)
+	defer func() { hang <- struct{}{} }() // release the abandoned goroutine
+	var handled error
+	err := Run(func(e *Catcher) error {
+		e.DeferTimeout(time.Millisecond, func() error {
+			<-hang
+			return nil
+		}, Label("hang"), HandlerFunc(func(s State, err error) error {
+			handled = err
+			return err
+		}))
+		return nil
+	})
+	var te *TimeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("got %v; want *TimeoutError", err)
+	}
+	if te.Label != "hang" || te.Timeout != time.Millisecond {
+		t.Errorf("got %+v; want label hang and timeout 1ms", te)
+	}
+	if handled != err {
+		t.Errorf("timeout error did not pass through the handlers")
+	}
+}
+
+func TestTimeoutResult(t *testing.T) {
+	errFail := errors.New("fail")
+	err := Run(func(e *Catcher) error {
+		e.Defer(func() error { return errFail }, Timeout(time.Hour))
+		return nil
+	})
+	if err != errFail {
+		t.Errorf("got %v; want %v", err, errFail)
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("got %v; want boom", r)
+		}
+	}()
+	Run(func(e *Catcher) error {
+		e.DeferTimeout(time.Hour, func() { panic("boom") })
+		return nil
+	})
+}
+
+func TestTimeoutSnapshot(t *testing.T) {
+	hang := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	errFail := errors.New("fail")
+	err := Run(func(e *Catcher) error {
+		e.Defer(func() error { return errFail })
+		e.Defer(func(s State) error {
+			<-hang
+			done <- s.Err() // must not observe errors recorded later
+			return nil
+		}, Timeout(time.Millisecond))
+		return nil
+	})
+	hang <- struct{}{}
+	if got := <-done; got != nil {
+		t.Errorf("abandoned function: got %v; want nil", got)
+	}
+	if _, ok := err.(*TimeoutError); !ok {
+		t.Errorf("got %v; want *TimeoutError", err)
+	}
+}
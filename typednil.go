@@ -0,0 +1,59 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A TypedNilError reports that Must was passed a non-nil error interface
+// holding a nil value, such as a nil *MyError. This is usually a bug: the
+// function returning the error intended to report success.
+type TypedNilError struct {
+	// Type is the dynamic type of the error.
+	Type reflect.Type
+}
+
+func (e *TypedNilError) Error() string {
+	return fmt.Sprintf("errd: Must called with nil %v in non-nil error interface", e.Type)
+}
+
+// TypedNil returns an Option that checks errors passed to Must for nil values
+// of a non-nil error interface. Such an error is replaced by a *TypedNilError
+// describing it, which is then passed to h. If h returns nil, Must continues
+// as if the error were nil. Otherwise, the returned error is handled like any
+// other error. If h is nil, the *TypedNilError is handled like any other
+// error.
+//
+// Use TypedNil(Discard) to treat typed nil errors as nil.
+func TypedNil(h Handler) Option {
+	return option(func(c *core) {
+		c.checkTypedNil = true
+		c.ext().typedNilHandler = h
+	})
+}
+
+// checkTypedNil replaces err with a *TypedNilError if it is a typed nil and
+// passes it to the configured handler. It returns nil if the error is to be
+// ignored.
+func checkTypedNil(e *Catcher, err error) error {
+	if !isTypedNil(err) {
+		return err
+	}
+	err = &TypedNilError{reflect.TypeOf(err)}
+	if h := e.extOrZero().typedNilHandler; h != nil {
+		return h.Handle((*state)(e), err)
+	}
+	return err
+}
+
+func isTypedNil(err error) bool {
+	switch v := reflect.ValueOf(err); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errc
+
+import "testing"
+
+type ptrError struct{}
+
+func (*ptrError) Error() string { return "ptrError" }
+
+func returnsTypedNil() error {
+	var p *ptrError
+	return p
+}
+
+func TestTypedNil(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		options []Handler
+		want    string
+	}{{
+		desc: "unchecked",
+		want: "ptrError",
+	}, {
+		desc:    "report",
+		options: []Handler{TypedNil(nil)},
+		want:    "errd: Must called with nil *errc.ptrError in non-nil error interface",
+	}, {
+		desc:    "treat as nil",
+		options: []Handler{TypedNil(Discard)},
+		want:    "",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := func() (err error) {
+				e := Catch(&err, tc.options...)
+				defer e.Handle()
+				e.Must(returnsTypedNil())
+				return nil
+			}()
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}